@@ -0,0 +1,185 @@
+package itsy
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"expvar"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultAdminPrefix is the path prefix the admin group is mounted at.
+const DefaultAdminPrefix = "/debug"
+
+type (
+	// AdminConfig configures the admin group.
+	AdminConfig struct {
+		Prefix   string                   // The path prefix, DefaultAdminPrefix if empty.
+		Auth     func(*http.Request) bool // Authorizes admin requests. If nil, mounted groups deny every request.
+		Listener net.Listener             // Serve the group on this listener instead of mounting it.
+	}
+	// Admin is the group of debug and admin endpoints.
+	Admin struct {
+		itsy    *Itsy
+		config  AdminConfig
+		mux     *http.ServeMux
+		started time.Time
+	}
+	// RouteInfo describes a registered resource.
+	RouteInfo struct {
+		Path    string   `json:"path"`
+		Methods []string `json:"methods"`
+		Links   []Link   `json:"links"`
+	}
+	// RuntimeStats is a snapshot of the Go runtime.
+	RuntimeStats struct {
+		GoVersion    string `json:"goVersion"`
+		GOMAXPROCS   int    `json:"gomaxprocs"`
+		NumCPU       int    `json:"numCPU"`
+		NumGoroutine int    `json:"numGoroutine"`
+		Uptime       string `json:"uptime"`
+		HeapAlloc    uint64 `json:"heapAlloc"`
+		HeapSys      uint64 `json:"heapSys"`
+		HeapObjects  uint64 `json:"heapObjects"`
+		TotalAlloc   uint64 `json:"totalAlloc"`
+		Sys          uint64 `json:"sys"`
+		NumGC        uint32 `json:"numGC"`
+		PauseTotalNs uint64 `json:"pauseTotalNs"`
+	}
+)
+
+// Admin creates the admin group, which serves pprof, expvar, the route table,
+// the resource graph, the log level and runtime stats. The group is mounted at
+// its prefix unless a separate listener is configured, in which case it is served there.
+// Without Auth, a mounted group denies every request, while a separate listener serves
+// every client that can reach it.
+func (i *Itsy) Admin(config AdminConfig) *Admin {
+	if config.Prefix == "" {
+		config.Prefix = DefaultAdminPrefix
+	}
+	config.Prefix = "/" + strings.Trim(config.Prefix, "/")
+	if config.Auth == nil {
+		config.Auth = denyAll
+		if config.Listener != nil {
+			config.Auth = allowAll
+		}
+	}
+
+	a := &Admin{
+		itsy:    i,
+		config:  config,
+		mux:     http.NewServeMux(),
+		started: time.Now(),
+	}
+
+	p := config.Prefix
+	a.mux.HandleFunc(p+"/pprof/", pprof.Index)
+	a.mux.HandleFunc(p+"/pprof/cmdline", pprof.Cmdline)
+	a.mux.HandleFunc(p+"/pprof/profile", pprof.Profile)
+	a.mux.HandleFunc(p+"/pprof/symbol", pprof.Symbol)
+	a.mux.HandleFunc(p+"/pprof/trace", pprof.Trace)
+	a.mux.Handle(p+"/vars", expvar.Handler())
+	a.mux.Handle(p+"/loglevel", i.LogLevel)
+	a.mux.HandleFunc(p+"/routes", a.serveRoutes)
+	a.mux.HandleFunc(p+"/runtime", a.serveRuntime)
+	a.mux.HandleFunc(p+"/graph", a.serveGraph)
+
+	if config.Listener != nil {
+		i.admin = &http.Server{Handler: a, ErrorLog: zap.NewStdLog(i.Logger)}
+		go func() {
+			i.Logger.Info("Admin listening", zap.String("address", config.Listener.Addr().String()))
+			if err := i.admin.Serve(config.Listener); err != nil && err != http.ErrServerClosed {
+				i.Logger.Error("Admin server stopped", zap.Error(err))
+			}
+		}()
+	} else {
+		i.Mount(p, a)
+	}
+
+	return a
+}
+
+// Handle registers an additional admin endpoint relative to the admin prefix.
+func (a *Admin) Handle(path string, handler http.Handler) {
+	a.mux.Handle(a.config.Prefix+"/"+strings.TrimPrefix(path, "/"), handler)
+}
+
+// ServeHTTP authorizes the request and serves the admin endpoint.
+func (a *Admin) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	if !a.config.Auth(req) {
+		a.itsy.sendHTTPError(StatusUnauthorized, "Admin access denied", res, a.itsy.Logger)
+		return
+	}
+	a.mux.ServeHTTP(res, req)
+}
+
+// Routes returns the route table.
+func (a *Admin) Routes() []RouteInfo {
+	routes := make([]RouteInfo, 0, len(a.itsy.resources))
+	for path, resource := range a.itsy.resources {
+		routes = append(routes, RouteInfo{
+			Path:    path,
+			Methods: resourceMethods(resource),
+			Links:   resource.Links(),
+		})
+	}
+	sort.Slice(routes, func(x, y int) bool { return routes[x].Path < routes[y].Path })
+	return routes
+}
+
+// RuntimeStats returns a snapshot of the Go runtime.
+func (a *Admin) RuntimeStats() RuntimeStats {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return RuntimeStats{
+		GoVersion:    runtime.Version(),
+		GOMAXPROCS:   runtime.GOMAXPROCS(0),
+		NumCPU:       runtime.NumCPU(),
+		NumGoroutine: runtime.NumGoroutine(),
+		Uptime:       time.Since(a.started).String(),
+		HeapAlloc:    m.HeapAlloc,
+		HeapSys:      m.HeapSys,
+		HeapObjects:  m.HeapObjects,
+		TotalAlloc:   m.TotalAlloc,
+		Sys:          m.Sys,
+		NumGC:        m.NumGC,
+		PauseTotalNs: m.PauseTotalNs,
+	}
+}
+
+func (a *Admin) serveRoutes(res http.ResponseWriter, req *http.Request) {
+	writeJSON(res, a.Routes())
+}
+
+func (a *Admin) serveRuntime(res http.ResponseWriter, req *http.Request) {
+	writeJSON(res, a.RuntimeStats())
+}
+
+// AdminBasicAuth authorizes requests carrying the given basic auth credentials.
+func AdminBasicAuth(username, password string) func(*http.Request) bool {
+	return func(req *http.Request) bool {
+		u, p, ok := req.BasicAuth()
+		return ok &&
+			subtle.ConstantTimeCompare([]byte(u), []byte(username)) == 1 &&
+			subtle.ConstantTimeCompare([]byte(p), []byte(password)) == 1
+	}
+}
+
+// denyAll authorizes no request.
+func denyAll(*http.Request) bool { return false }
+
+// allowAll authorizes every request.
+func allowAll(*http.Request) bool { return true }
+
+// writeJSON writes v as a JSON response.
+func writeJSON(res http.ResponseWriter, v interface{}) {
+	res.Header().Set(HeaderContentType, MIMEAppJSON)
+	json.NewEncoder(res).Encode(v)
+}
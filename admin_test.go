@@ -0,0 +1,143 @@
+package itsy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestAdmin(t *testing.T) {
+	i := New()
+	i.Register("/users").GET(func(c Context) error {
+		return c.WriteString("users")
+	})
+	i.Admin(AdminConfig{Auth: AdminBasicAuth("admin", "secret")})
+
+	// Requests without credentials are rejected.
+	rr := httptest.NewRecorder()
+	i.ServeHTTP(rr, httptest.NewRequest(GET, "/debug/routes", nil))
+	if rr.Code != StatusUnauthorized {
+		t.Fatalf("Expected status %d, got %d", StatusUnauthorized, rr.Code)
+	}
+
+	// The route table lists the registered resources.
+	req := httptest.NewRequest(GET, "/debug/routes", nil)
+	req.SetBasicAuth("admin", "secret")
+	rr = httptest.NewRecorder()
+	i.ServeHTTP(rr, req)
+	var routes []RouteInfo
+	if err := json.Unmarshal(rr.Body.Bytes(), &routes); err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) != 1 || routes[0].Path != "/users" || routes[0].Methods[0] != GET {
+		t.Errorf("Unexpected routes: %+v", routes)
+	}
+
+	// pprof is reachable.
+	req = httptest.NewRequest(GET, "/debug/pprof/", nil)
+	req.SetBasicAuth("admin", "secret")
+	rr = httptest.NewRecorder()
+	i.ServeHTTP(rr, req)
+	if rr.Code != StatusOK || !strings.Contains(rr.Body.String(), "goroutine") {
+		t.Errorf("Expected the pprof index, got %d", rr.Code)
+	}
+
+	// The log level can be changed at runtime.
+	req = httptest.NewRequest(PUT, "/debug/loglevel", strings.NewReader(`{"level":"warn"}`))
+	req.SetBasicAuth("admin", "secret")
+	rr = httptest.NewRecorder()
+	i.ServeHTTP(rr, req)
+	if i.LogLevel.Level() != zap.WarnLevel {
+		t.Errorf("Expected level %v, got %v", zap.WarnLevel, i.LogLevel.Level())
+	}
+
+	// Application resources are unaffected.
+	rr = httptest.NewRecorder()
+	i.ServeHTTP(rr, httptest.NewRequest(GET, "/users", nil))
+	if rr.Body.String() != "users" {
+		t.Errorf("Expected %q, got %q", "users", rr.Body.String())
+	}
+}
+
+func TestAdminDeniedByDefault(t *testing.T) {
+	i := New()
+	i.Admin(AdminConfig{})
+
+	// Without Auth, the mounted group denies every request, even from loopback peers.
+	req := httptest.NewRequest(GET, "/debug/runtime", nil)
+	req.RemoteAddr = "127.0.0.1:1234"
+	rr := httptest.NewRecorder()
+	i.ServeHTTP(rr, req)
+	if rr.Code != StatusUnauthorized {
+		t.Errorf("Expected status %d, got %d", StatusUnauthorized, rr.Code)
+	}
+}
+
+func TestAdminListener(t *testing.T) {
+	i := New()
+
+	// Serve the admin group on its own listener.
+	l, err := TCPListener("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	i.Admin(AdminConfig{Listener: l})
+
+	// The group is not mounted on the application.
+	rr := httptest.NewRecorder()
+	i.ServeHTTP(rr, httptest.NewRequest(GET, "/debug/runtime", nil))
+	if rr.Code != StatusNotFound {
+		t.Fatalf("Expected status %d, got %d", StatusNotFound, rr.Code)
+	}
+
+	// Clients that can reach the admin listener are authorized.
+	var stats RuntimeStats
+	if err := json.Unmarshal([]byte(get(t, http.DefaultClient, "http://"+l.Addr().String()+"/debug/runtime")), &stats); err != nil {
+		t.Fatal(err)
+	}
+	if stats.NumGoroutine == 0 {
+		t.Errorf("Expected runtime stats, got %+v", stats)
+	}
+	i.admin.Close()
+}
+
+func TestShutdownAdmin(t *testing.T) {
+	i := New()
+
+	// Serve the admin group with an endpoint that blocks until released.
+	l, err := TCPListener("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	started, release := make(chan struct{}), make(chan struct{})
+	i.Admin(AdminConfig{Listener: l}).Handle("/block", http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		close(started)
+		<-release
+	}))
+	defer close(release)
+	go http.Get("http://" + l.Addr().String() + "/debug/block")
+	<-started
+
+	tcp, err := TCPListener("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- i.Serve(tcp) }()
+
+	// The application server is shut down even though the admin server can't be.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := i.Shutdown(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected %v, got %v", context.Canceled, err)
+	}
+	if err := <-done; !errors.Is(err, http.ErrServerClosed) {
+		t.Errorf("Expected %v, got %v", http.ErrServerClosed, err)
+	}
+}
@@ -0,0 +1,122 @@
+package itsy
+
+import (
+	"reflect"
+	"strconv"
+)
+
+const (
+	// PageParam is the query parameter of the page number.
+	PageParam = "page"
+	// PerPageParam is the query parameter of the page size.
+	PerPageParam = "per_page"
+	// MaxPerPage is the largest page size a client may ask for.
+	MaxPerPage = 100
+)
+
+type (
+	// Page is the pagination state of a request.
+	Page struct {
+		Number  int // The page number, from 1.
+		PerPage int // The number of items per page.
+	}
+	// Collection is a page of the items of a collection resource.
+	// Rendering it adds first, last, prev and next links for the pages.
+	Collection struct {
+		Items    interface{} `json:"items"`    // The items on the page, a slice.
+		Page     int         `json:"page"`     // The page number, from 1.
+		PerPage  int         `json:"per_page"` // The number of items per page.
+		Total    int         `json:"total"`    // The number of items on all pages.
+		Queries  []Query     `json:"-"`        // The queries the collection supports.
+		Template []Field     `json:"-"`        // The fields of a new item, by default those of the POST action.
+	}
+	// Query is a query template of a collection.
+	Query struct {
+		Rel    string  // The relation of the query.
+		Href   string  // The URL of the query.
+		Name   string  // The name of the query.
+		Prompt string  // A human-readable description of the query.
+		Fields []Field // The query parameters.
+	}
+)
+
+// ParsePage reads the pagination state from the query string, using perPage when the client asks for none.
+func ParsePage(c Context, perPage int) Page {
+	query := c.Request().URL.Query()
+	page := Page{Number: 1, PerPage: perPage}
+	if n, err := strconv.Atoi(query.Get(PageParam)); err == nil && n > 0 {
+		page.Number = n
+	}
+	if n, err := strconv.Atoi(query.Get(PerPageParam)); err == nil && n > 0 {
+		page.PerPage = n
+	}
+	if page.PerPage > MaxPerPage {
+		page.PerPage = MaxPerPage
+	}
+	if page.PerPage < 1 {
+		page.PerPage = 1
+	}
+	return page
+}
+
+// Offset returns the index of the first item on the page.
+func (p Page) Offset() int {
+	return (p.Number - 1) * p.PerPage
+}
+
+// NewCollection creates a page of a collection of total items.
+func NewCollection(items interface{}, page Page, total int) *Collection {
+	return &Collection{Items: items, Page: page.Number, PerPage: page.PerPage, Total: total}
+}
+
+// Pages returns the number of pages, at least one.
+func (col *Collection) Pages() int {
+	if col.PerPage < 1 || col.Total <= col.PerPage {
+		return 1
+	}
+	return (col.Total + col.PerPage - 1) / col.PerPage
+}
+
+// Links returns the pagination links of the collection, keeping the request's other query parameters.
+func (col *Collection) Links(c Context) []Link {
+	pages := col.Pages()
+	links := []Link{col.pageLink(c, "first", 1)}
+	if col.Page > 1 {
+		links = append(links, col.pageLink(c, "prev", min(col.Page-1, pages)))
+	}
+	if col.Page < pages {
+		links = append(links, col.pageLink(c, "next", col.Page+1))
+	}
+	return append(links, col.pageLink(c, "last", pages))
+}
+
+// pageLink creates a link to a page of the collection.
+func (col *Collection) pageLink(c Context, rel string, page int) Link {
+	u := *c.Request().URL
+	query := u.Query()
+	query.Set(PageParam, strconv.Itoa(page))
+	if col.PerPage > 0 {
+		query.Set(PerPageParam, strconv.Itoa(col.PerPage))
+	}
+	u.RawQuery = query.Encode()
+	return Link{Href: u.RequestURI(), Rel: rel}
+}
+
+// collectionItems returns the items of rendered data: those of a collection, the elements of a slice, or the value itself.
+func collectionItems(data interface{}) []interface{} {
+	if col, ok := data.(*Collection); ok {
+		data = col.Items
+	}
+	if data == nil {
+		return []interface{}{}
+	}
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return []interface{}{data}
+	}
+	items := make([]interface{}, v.Len())
+	for n := range items {
+		items[n] = v.Index(n).Interface()
+	}
+	return items
+}
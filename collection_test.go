@@ -0,0 +1,84 @@
+package itsy
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParsePage(t *testing.T) {
+	i := New()
+	for query, expected := range map[string]Page{
+		"":                     {Number: 1, PerPage: 20},
+		"?page=3":              {Number: 3, PerPage: 20},
+		"?page=2&per_page=5":   {Number: 2, PerPage: 5},
+		"?page=0&per_page=500": {Number: 1, PerPage: MaxPerPage},
+		"?page=x&per_page=-1":  {Number: 1, PerPage: 20},
+	} {
+		c := i.prepareRequestContext(httptest.NewRecorder(), httptest.NewRequest(GET, "/items"+query, nil), "/items")
+		if got := ParsePage(c, 20); got != expected {
+			t.Errorf("Expected %+v for %q, got %+v", expected, query, got)
+		}
+	}
+	if offset := (Page{Number: 3, PerPage: 10}).Offset(); offset != 20 {
+		t.Errorf("Expected offset 20, got %d", offset)
+	}
+}
+
+func TestCollectionLinks(t *testing.T) {
+	items := []string{"a", "b", "c", "d", "e", "f", "g"}
+
+	i := New()
+	i.Register("/items").GET(func(c Context) error {
+		page := ParsePage(c, 3)
+		end := min(page.Offset()+page.PerPage, len(items))
+		return c.Render(StatusOK, NewCollection(items[min(page.Offset(), end):end], page, len(items)))
+	})
+
+	links := func(query string) map[string]string {
+		req := httptest.NewRequest(GET, "/items"+query, nil)
+		req.Header.Set(HeaderAccept, MIMEAppJSON)
+		rr := httptest.NewRecorder()
+		i.ServeHTTP(rr, req)
+		var doc struct {
+			Links []Link `json:"links"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &doc); err != nil {
+			t.Fatal(err)
+		}
+		rels := make(map[string]string)
+		for _, link := range doc.Links {
+			rels[link.Rel] = link.Href
+		}
+		return rels
+	}
+
+	for query, expected := range map[string]map[string]string{
+		"": {
+			"first": "/items?page=1&per_page=3",
+			"next":  "/items?page=2&per_page=3",
+			"last":  "/items?page=3&per_page=3",
+		},
+		"?page=2&sort=name": {
+			"first": "/items?page=1&per_page=3&sort=name",
+			"prev":  "/items?page=1&per_page=3&sort=name",
+			"next":  "/items?page=3&per_page=3&sort=name",
+			"last":  "/items?page=3&per_page=3&sort=name",
+		},
+		"?page=3": {
+			"first": "/items?page=1&per_page=3",
+			"prev":  "/items?page=2&per_page=3",
+			"last":  "/items?page=3&per_page=3",
+		},
+	} {
+		got := links(query)
+		if len(got) != len(expected) {
+			t.Errorf("Unexpected links for %q: %v", query, got)
+		}
+		for rel, href := range expected {
+			if got[rel] != href {
+				t.Errorf("Expected %s link %q for %q, got %q", rel, href, query, got[rel])
+			}
+		}
+	}
+}
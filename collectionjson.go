@@ -0,0 +1,192 @@
+package itsy
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// MIMEAppCollectionJSON is the Collection+JSON media type.
+const MIMEAppCollectionJSON = "application/vnd.collection+json"
+
+type (
+	// CollectionJSONDocument is a Collection+JSON document, as encoded and decoded.
+	CollectionJSONDocument struct {
+		Collection CollectionJSON `json:"collection"` // The collection.
+	}
+	// CollectionJSON is the collection object of a Collection+JSON document.
+	CollectionJSON struct {
+		Version  string                  `json:"version"`            // The version of the format.
+		Href     string                  `json:"href,omitempty"`     // The URL of the collection.
+		Links    []CollectionJSONLink    `json:"links,omitempty"`    // The links of the collection.
+		Items    []CollectionJSONItem    `json:"items"`              // The items.
+		Queries  []CollectionJSONQuery   `json:"queries,omitempty"`  // The query templates.
+		Template *CollectionJSONTemplate `json:"template,omitempty"` // The template of a new item.
+		Error    *CollectionJSONError    `json:"error,omitempty"`    // The error, if the request failed.
+	}
+	// CollectionJSONLink is a link of a Collection+JSON collection or item.
+	CollectionJSONLink struct {
+		Rel    string `json:"rel"`              // The relation of the link.
+		Href   string `json:"href"`             // The URL of the linked resource.
+		Name   string `json:"name,omitempty"`   // The name of the link.
+		Prompt string `json:"prompt,omitempty"` // A human-readable description of the link.
+	}
+	// CollectionJSONItem is an item of a Collection+JSON collection.
+	CollectionJSONItem struct {
+		Href  string               `json:"href,omitempty"`  // The URL of the item.
+		Data  []CollectionJSONData `json:"data"`            // The properties of the item.
+		Links []CollectionJSONLink `json:"links,omitempty"` // The links of the item.
+	}
+	// CollectionJSONData is a property of an item, query or template.
+	CollectionJSONData struct {
+		Name   string      `json:"name"`             // The name of the property.
+		Value  interface{} `json:"value,omitempty"`  // The value of the property.
+		Prompt string      `json:"prompt,omitempty"` // A human-readable description of the property.
+	}
+	// CollectionJSONQuery is a query template of a Collection+JSON collection.
+	CollectionJSONQuery struct {
+		Rel    string               `json:"rel"`              // The relation of the query.
+		Href   string               `json:"href"`             // The URL of the query.
+		Name   string               `json:"name,omitempty"`   // The name of the query.
+		Prompt string               `json:"prompt,omitempty"` // A human-readable description of the query.
+		Data   []CollectionJSONData `json:"data,omitempty"`   // The query parameters.
+	}
+	// CollectionJSONTemplate is the template of a new item.
+	CollectionJSONTemplate struct {
+		Data []CollectionJSONData `json:"data"` // The fields of the item.
+	}
+	// CollectionJSONError is the error of a Collection+JSON document.
+	CollectionJSONError struct {
+		Title   string `json:"title"`   // The status text.
+		Code    string `json:"code"`    // The HTTP status code.
+		Message string `json:"message"` // The message of the error.
+	}
+	// collectionJSONEncoder renders a representation as a Collection+JSON document.
+	collectionJSONEncoder struct{}
+)
+
+// Encode writes the representation as a Collection+JSON document.
+func (collectionJSONEncoder) Encode(w io.Writer, rep *Representation) error {
+	doc, err := newCollectionJSON(rep)
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(CollectionJSONDocument{Collection: doc})
+}
+
+// EncodeError writes the error as a Collection+JSON document.
+func (collectionJSONEncoder) EncodeError(w io.Writer, err *HTTPError) error {
+	return json.NewEncoder(w).Encode(CollectionJSONDocument{Collection: CollectionJSON{
+		Version: "1.0",
+		Items:   []CollectionJSONItem{},
+		Error:   &CollectionJSONError{Title: err.Title(), Code: strconv.Itoa(err.Status), Message: err.Message},
+	}})
+}
+
+// newCollectionJSON creates the collection object of a representation.
+// Items whose data is a Linker get its links, and a "self" link becomes the item's href.
+func newCollectionJSON(rep *Representation) (CollectionJSON, error) {
+	doc := CollectionJSON{
+		Version: "1.0",
+		Href:    rep.Self,
+		Links:   collectionJSONLinks(rep.Hypermedia.Links),
+		Items:   []CollectionJSONItem{},
+	}
+
+	for _, value := range collectionItems(rep.Data) {
+		properties, err := objectProperties(value)
+		if err != nil {
+			return CollectionJSON{}, err
+		}
+		item := CollectionJSONItem{Data: collectionJSONData(properties)}
+		if linker, ok := value.(Linker); ok {
+			for _, link := range linker.Links(rep.Context) {
+				if link.Rel == "self" {
+					item.Href = link.Href
+					continue
+				}
+				item.Links = append(item.Links, collectionJSONLinks([]Link{link})...)
+			}
+		}
+		doc.Items = append(doc.Items, item)
+	}
+
+	var template []Field
+	if col, ok := rep.Data.(*Collection); ok {
+		template = col.Template
+		for _, query := range col.Queries {
+			doc.Queries = append(doc.Queries, CollectionJSONQuery{
+				Rel:    query.Rel,
+				Href:   query.Href,
+				Name:   query.Name,
+				Prompt: query.Prompt,
+				Data:   collectionJSONFields(query.Fields),
+			})
+		}
+	}
+	if template == nil {
+		for _, action := range rep.Hypermedia.Actions {
+			if action.Method == POST {
+				template = action.Fields
+			}
+		}
+	}
+	if len(template) > 0 {
+		doc.Template = &CollectionJSONTemplate{Data: collectionJSONFields(template)}
+	}
+	return doc, nil
+}
+
+// collectionJSONLinks converts links to Collection+JSON links.
+func collectionJSONLinks(links []Link) []CollectionJSONLink {
+	converted := make([]CollectionJSONLink, 0, len(links))
+	for _, link := range links {
+		converted = append(converted, CollectionJSONLink{Rel: link.Rel, Href: link.Href, Name: link.Name, Prompt: link.Title})
+	}
+	return converted
+}
+
+// collectionJSONData converts properties to Collection+JSON data, in name order.
+func collectionJSONData(properties map[string]interface{}) []CollectionJSONData {
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	data := make([]CollectionJSONData, 0, len(names))
+	for _, name := range names {
+		data = append(data, CollectionJSONData{Name: name, Value: properties[name]})
+	}
+	return data
+}
+
+// collectionJSONFields converts fields to Collection+JSON data.
+func collectionJSONFields(fields []Field) []CollectionJSONData {
+	data := make([]CollectionJSONData, 0, len(fields))
+	for _, field := range fields {
+		data = append(data, CollectionJSONData{Name: field.Name, Value: field.Value, Prompt: field.Title})
+	}
+	return data
+}
+
+// Item returns the item with the href.
+func (c *CollectionJSON) Item(href string) (CollectionJSONItem, bool) {
+	for _, item := range c.Items {
+		if item.Href == href {
+			return item, true
+		}
+	}
+	return CollectionJSONItem{}, false
+}
+
+// Value returns the value of the item's property.
+func (i CollectionJSONItem) Value(name string) interface{} {
+	for _, data := range i.Data {
+		if data.Name == name {
+			return data.Value
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package itsy
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+type collectionJSONOrder struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
+
+func (o collectionJSONOrder) Links(c Context) []Link {
+	return []Link{
+		{Href: "/orders/" + o.ID, Rel: "self"},
+		{Href: "/orders/" + o.ID + "/customer", Rel: "customer"},
+	}
+}
+
+func TestCollectionJSON(t *testing.T) {
+	orders := []collectionJSONOrder{{ID: "1", Status: "open"}, {ID: "2", Status: "shipped"}}
+
+	i := New()
+	i.Register("/orders").GET(func(c Context) error {
+		col := NewCollection(orders, ParsePage(c, 10), len(orders))
+		col.Queries = []Query{{Rel: "search", Href: "/orders", Name: "status", Fields: []Field{{Name: "status", Title: "Status"}}}}
+		return c.Render(StatusOK, col)
+	})
+	i.Resource("/orders").POST(func(c Context) error { return nil })
+	i.Resource("/orders").(ActionDescriber).Action(POST, "create", Field{Name: "status", Title: "Status", Value: "open"})
+
+	req := httptest.NewRequest(GET, "/orders", nil)
+	req.Header.Set(HeaderAccept, MIMEAppCollectionJSON)
+	rr := httptest.NewRecorder()
+	i.ServeHTTP(rr, req)
+
+	if rr.Header().Get(HeaderContentType) != MIMEAppCollectionJSON {
+		t.Fatalf("Unexpected content type: %q", rr.Header().Get(HeaderContentType))
+	}
+	var doc CollectionJSONDocument
+	if err := json.Unmarshal(rr.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	col := doc.Collection
+
+	if col.Version != "1.0" || col.Href != "/orders" || len(col.Items) != 2 {
+		t.Fatalf("Unexpected collection: %+v", col)
+	}
+	item, ok := col.Item("/orders/2")
+	if !ok || item.Value("status") != "shipped" || len(item.Links) != 1 || item.Links[0].Href != "/orders/2/customer" {
+		t.Errorf("Unexpected item: %+v", item)
+	}
+
+	// Pagination links, queries and the POST action's template.
+	if len(col.Links) != 2 || col.Links[1].Rel != "last" || col.Links[1].Href != "/orders?page=1&per_page=10" {
+		t.Errorf("Unexpected links: %+v", col.Links)
+	}
+	if len(col.Queries) != 1 || col.Queries[0].Rel != "search" || col.Queries[0].Data[0].Prompt != "Status" {
+		t.Errorf("Unexpected queries: %+v", col.Queries)
+	}
+	if col.Template == nil || len(col.Template.Data) != 1 || col.Template.Data[0].Name != "status" || col.Template.Data[0].Value != "open" {
+		t.Errorf("Unexpected template: %+v", col.Template)
+	}
+
+	// Errors are reported in the collection.
+	req = httptest.NewRequest(GET, "/missing", nil)
+	req.Header.Set(HeaderAccept, MIMEAppCollectionJSON)
+	rr = httptest.NewRecorder()
+	i.ServeHTTP(rr, req)
+	doc = CollectionJSONDocument{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Collection.Error == nil || doc.Collection.Error.Code != "404" {
+		t.Errorf("Unexpected error: %+v", doc.Collection)
+	}
+}
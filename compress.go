@@ -0,0 +1,279 @@
+package itsy
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// DefaultCompressMinLength is the smallest response body that is compressed.
+	DefaultCompressMinLength = 1024
+
+	// Define Compression Header Names
+	HeaderAcceptEncoding  = "Accept-Encoding"
+	HeaderContentEncoding = "Content-Encoding"
+	HeaderContentLength   = "Content-Length"
+
+	// Define Content Encodings
+	EncodingGzip     = "gzip"
+	EncodingDeflate  = "deflate"
+	EncodingIdentity = "identity"
+)
+
+// incompressibleTypes are content types that are already compressed.
+var incompressibleTypes = []string{
+	"image/", "video/", "audio/", "font/woff",
+	"application/zip", "application/gzip", "application/x-gzip", "application/zstd",
+	"application/x-bzip2", "application/x-7z-compressed", "application/pdf", "application/octet-stream",
+}
+
+type (
+	// CompressConfig configures the compression middleware.
+	CompressConfig struct {
+		Level     *int // The compression level, gzip.HuffmanOnly to gzip.BestCompression, gzip.DefaultCompression if nil.
+		MinLength int  // The smallest body that is compressed, DefaultCompressMinLength if zero.
+	}
+	// compressor is a pooled gzip or deflate writer.
+	compressor interface {
+		io.WriteCloser
+		Flush() error
+		Reset(w io.Writer)
+	}
+	// compressWriter buffers the start of a response until it can decide whether to compress it.
+	compressWriter struct {
+		http.ResponseWriter
+		encoding  string
+		pool      *sync.Pool
+		minLength int
+		method    string
+		status    int
+		buf       []byte
+		decided   bool
+		cw        compressor
+	}
+)
+
+// Compress creates a middleware that compresses responses with gzip or deflate,
+// as negotiated with the Accept-Encoding header. Deflate bodies use the zlib format
+// the content coding is defined as. The level is a pointer so gzip.NoCompression,
+// which is zero, can be told apart from the default. Compress panics if the level is invalid.
+func Compress(config CompressConfig) Middleware {
+	level := gzip.DefaultCompression
+	if config.Level != nil {
+		level = *config.Level
+	}
+	if level < gzip.HuffmanOnly || level > gzip.BestCompression {
+		panic("itsy: invalid compression level " + strconv.Itoa(level))
+	}
+	if config.MinLength <= 0 {
+		config.MinLength = DefaultCompressMinLength
+	}
+
+	pools := map[string]*sync.Pool{
+		EncodingGzip: {New: func() interface{} {
+			w, err := gzip.NewWriterLevel(io.Discard, level)
+			if err != nil {
+				panic(err)
+			}
+			return w
+		}},
+		EncodingDeflate: {New: func() interface{} {
+			w, err := zlib.NewWriterLevel(io.Discard, level)
+			if err != nil {
+				panic(err)
+			}
+			return w
+		}},
+	}
+
+	return func(c Context, next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			res := c.Response()
+			res.Header().Add(HeaderVary, HeaderAcceptEncoding)
+
+			encoding := negotiateEncoding(c.Request().Header.Get(HeaderAcceptEncoding))
+			if encoding == "" {
+				return next(c)
+			}
+
+			original := res.Writer
+			w := &compressWriter{
+				ResponseWriter: original,
+				encoding:       encoding,
+				pool:           pools[encoding],
+				minLength:      config.MinLength,
+				method:         c.Request().Method,
+			}
+			res.Writer = w
+			defer func() {
+				w.Close()
+				res.Writer = original
+			}()
+
+			return next(c)
+		}
+	}
+}
+
+// negotiateEncoding picks gzip or deflate from an Accept-Encoding header, or "" for no compression.
+func negotiateEncoding(header string) string {
+	best, bestQ := "", 0.0
+	wildcard := -1.0
+	q := map[string]float64{}
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		value := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				value = f
+			}
+		}
+		if name == "*" {
+			wildcard = value
+			continue
+		}
+		q[name] = value
+	}
+
+	// Prefer gzip over deflate when both are equally acceptable.
+	for _, encoding := range []string{EncodingGzip, EncodingDeflate} {
+		value, ok := q[encoding]
+		if !ok {
+			value = wildcard
+		}
+		if value > bestQ {
+			best, bestQ = encoding, value
+		}
+	}
+	return best
+}
+
+// compressible returns true if a response with the header may be compressed.
+func compressible(header http.Header) bool {
+	if header.Get(HeaderContentEncoding) != "" {
+		return false
+	}
+	contentType := strings.ToLower(header.Get(HeaderContentType))
+	if strings.HasPrefix(contentType, "image/svg") {
+		return true
+	}
+	for _, prefix := range incompressibleTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteHeader records the status code until the compression decision is made.
+func (w *compressWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+// Write buffers the body until it is long enough to decide, then writes through the compressor.
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = StatusOK
+	}
+	if !w.decided {
+		w.buf = append(w.buf, b...)
+		if len(w.buf) < w.minLength {
+			return len(b), nil
+		}
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	if w.cw != nil {
+		return w.cw.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// decide commits the headers, compressing the body if allowed, and writes the buffered body.
+func (w *compressWriter) decide(compress bool) error {
+	w.decided = true
+	if w.status == 0 {
+		w.status = StatusOK
+	}
+
+	header := w.ResponseWriter.Header()
+	if header.Get(HeaderContentType) == "" && len(w.buf) > 0 {
+		header.Set(HeaderContentType, http.DetectContentType(w.buf))
+	}
+	bodyless := w.method == HEAD || w.status == StatusNoContent || w.status == http.StatusNotModified || w.status < StatusOK
+	if compress && !bodyless && compressible(header) {
+		header.Del(HeaderContentLength)
+		header.Set(HeaderContentEncoding, w.encoding)
+		w.cw = w.pool.Get().(compressor)
+		w.cw.Reset(w.ResponseWriter)
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if w.cw != nil {
+		_, err := w.cw.Write(buf)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+
+// Flush commits the response, compressing it if allowed, and flushes what has been written.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide(true)
+	}
+	if w.cw != nil {
+		w.cw.Flush()
+	}
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack hijacks the underlying connection.
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// Unwrap returns the wrapped response writer, for http.ResponseController.
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Close writes a short body uncompressed, or finishes the compressed body and returns the compressor to its pool.
+func (w *compressWriter) Close() error {
+	if !w.decided {
+		if w.status == 0 && len(w.buf) == 0 {
+			return nil
+		}
+		if err := w.decide(false); err != nil {
+			return err
+		}
+	}
+	if w.cw == nil {
+		return nil
+	}
+	err := w.cw.Close()
+	w.cw.Reset(io.Discard)
+	w.pool.Put(w.cw)
+	w.cw = nil
+	return err
+}
@@ -0,0 +1,128 @@
+package itsy
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCompress(t *testing.T) {
+	i := New()
+	i.Use(Compress(CompressConfig{MinLength: 64}))
+
+	long := strings.Repeat("hypermedia ", 100)
+	i.Register("/long").GET(func(c Context) error {
+		c.Response().Header().Set(HeaderContentType, MIMETextPlain)
+		c.Response().WriteHeader(StatusNotFound)
+		return c.WriteString(long)
+	})
+	i.Register("/short").GET(func(c Context) error {
+		return c.WriteString("short")
+	})
+	i.Register("/image").GET(func(c Context) error {
+		c.Response().Header().Set(HeaderContentType, "image/png")
+		return c.WriteString(long)
+	})
+
+	// A long text response is gzipped, keeping its status.
+	req := httptest.NewRequest(GET, "/long", nil)
+	req.Header.Set(HeaderAcceptEncoding, "deflate;q=0.5, gzip")
+	rr := httptest.NewRecorder()
+	i.ServeHTTP(rr, req)
+	if rr.Code != StatusNotFound || rr.Header().Get(HeaderContentEncoding) != EncodingGzip || rr.Header().Get(HeaderVary) != HeaderAcceptEncoding {
+		t.Fatalf("Unexpected response: %d %v", rr.Code, rr.Header())
+	}
+	gz, err := gzip.NewReader(rr.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, _ := io.ReadAll(gz); string(body) != long {
+		t.Errorf("Expected the decompressed body to match")
+	}
+
+	// Deflate is used when preferred.
+	req = httptest.NewRequest(GET, "/long", nil)
+	req.Header.Set(HeaderAcceptEncoding, "gzip;q=0.1, deflate")
+	rr = httptest.NewRecorder()
+	i.ServeHTTP(rr, req)
+	if rr.Header().Get(HeaderContentEncoding) != EncodingDeflate {
+		t.Fatalf("Expected deflate, got %v", rr.Header())
+	}
+	zr, err := zlib.NewReader(rr.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, _ := io.ReadAll(zr); string(body) != long {
+		t.Errorf("Expected the inflated body to match")
+	}
+
+	// Short and already compressed responses are sent as is.
+	for _, path := range []string{"/short", "/image"} {
+		req = httptest.NewRequest(GET, path, nil)
+		req.Header.Set(HeaderAcceptEncoding, EncodingGzip)
+		rr = httptest.NewRecorder()
+		i.ServeHTTP(rr, req)
+		if rr.Header().Get(HeaderContentEncoding) != "" || rr.Code != StatusOK {
+			t.Errorf("Expected %s to be uncompressed, got %d %v", path, rr.Code, rr.Header())
+		}
+	}
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	for header, expected := range map[string]string{
+		"":                      "",
+		"gzip":                  EncodingGzip,
+		"deflate, gzip":         EncodingGzip,
+		"gzip;q=0, deflate":     EncodingDeflate,
+		"*":                     EncodingGzip,
+		"*;q=0":                 "",
+		"identity":              "",
+		"br, GZIP;q=0.8":        EncodingGzip,
+		"gzip;q=0.2, *;q=0.5":   EncodingDeflate,
+		"deflate;q=0.9, gzip;q": EncodingGzip,
+	} {
+		if got := negotiateEncoding(header); got != expected {
+			t.Errorf("Expected %q for %q, got %q", expected, header, got)
+		}
+	}
+}
+
+func TestCompressInvalidLevel(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected Compress to panic with an invalid level")
+		}
+	}()
+	level := 42
+	Compress(CompressConfig{Level: &level})
+}
+
+func TestCompressNoCompression(t *testing.T) {
+	i := New()
+	level := gzip.NoCompression
+	i.Use(Compress(CompressConfig{Level: &level, MinLength: 64}))
+
+	long := strings.Repeat("hypermedia ", 100)
+	i.Register("/long").GET(func(c Context) error {
+		return c.WriteString(long)
+	})
+
+	// Stored blocks are larger than the body they hold.
+	req := httptest.NewRequest(GET, "/long", nil)
+	req.Header.Set(HeaderAcceptEncoding, EncodingGzip)
+	rr := httptest.NewRecorder()
+	i.ServeHTTP(rr, req)
+	if rr.Header().Get(HeaderContentEncoding) != EncodingGzip || rr.Body.Len() <= len(long) {
+		t.Fatalf("Expected an uncompressed gzip body, got %d bytes %v", rr.Body.Len(), rr.Header())
+	}
+	gz, err := gzip.NewReader(rr.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, _ := io.ReadAll(gz); string(body) != long {
+		t.Errorf("Expected the decompressed body to match")
+	}
+}
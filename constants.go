@@ -7,45 +7,73 @@ const (
 	DefaultPort = ":8080"
 
 	// Define HTTP Methods
-	GET    = http.MethodGet
-	POST   = http.MethodPost
-	PUT    = http.MethodPut
-	PATCH  = http.MethodPatch
-	DELETE = http.MethodDelete
+	GET     = http.MethodGet
+	POST    = http.MethodPost
+	PUT     = http.MethodPut
+	PATCH   = http.MethodPatch
+	DELETE  = http.MethodDelete
+	HEAD    = http.MethodHead
+	OPTIONS = http.MethodOptions
 
 	// Define HTTP Status Codes
-	StatusOK                  = http.StatusOK                  // 200
-	StatusBadRequest          = http.StatusBadRequest          // 400
-	StatusUnauthorized        = http.StatusUnauthorized        // 401
-	StatusForbidden           = http.StatusForbidden           // 403
-	StatusNotFound            = http.StatusNotFound            // 404
-	StatusMethodNotAllowed    = http.StatusMethodNotAllowed    // 405
-	StatusInternalServerError = http.StatusInternalServerError // 500
+	StatusOK                   = http.StatusOK                   // 200
+	StatusNoContent            = http.StatusNoContent            // 204
+	StatusBadRequest           = http.StatusBadRequest           // 400
+	StatusUnauthorized         = http.StatusUnauthorized         // 401
+	StatusForbidden            = http.StatusForbidden            // 403
+	StatusNotFound             = http.StatusNotFound             // 404
+	StatusMethodNotAllowed     = http.StatusMethodNotAllowed     // 405
+	StatusNotAcceptable        = http.StatusNotAcceptable        // 406
+	StatusConflict             = http.StatusConflict             // 409
+	StatusUnsupportedMediaType = http.StatusUnsupportedMediaType // 415
+	StatusUnprocessableEntity  = http.StatusUnprocessableEntity  // 422
+	StatusInternalServerError  = http.StatusInternalServerError  // 500
+	StatusServiceUnavailable   = http.StatusServiceUnavailable   // 503
 
 	// Define HTTP Header Names
 	HeaderAccept        = "Accept"
 	HeaderContentType   = "Content-Type"
 	HeaderAuthorization = "Authorization"
+	HeaderAllow         = "Allow"
+	HeaderOrigin        = "Origin"
+	HeaderVary          = "Vary"
+	HeaderLink          = "Link"
+
+	// Define CORS Header Names
+	HeaderAccessControlRequestMethod    = "Access-Control-Request-Method"
+	HeaderAccessControlRequestHeaders   = "Access-Control-Request-Headers"
+	HeaderAccessControlAllowOrigin      = "Access-Control-Allow-Origin"
+	HeaderAccessControlAllowMethods     = "Access-Control-Allow-Methods"
+	HeaderAccessControlAllowHeaders     = "Access-Control-Allow-Headers"
+	HeaderAccessControlAllowCredentials = "Access-Control-Allow-Credentials"
+	HeaderAccessControlExposeHeaders    = "Access-Control-Expose-Headers"
+	HeaderAccessControlMaxAge           = "Access-Control-Max-Age"
 
 	// Define MIME Types
 	MIMETextHTML  = "text/html"
 	MIMEAppJSON   = "application/json"
 	MIMETextPlain = "text/plain"
+	MIMEAppForm   = "application/x-www-form-urlencoded"
 
 	linkTemplate = `
 	{{range .}}
-	<a href="{{.Href}}" rel="{{.Rel}}"></a>
+	<a href="{{html .Href}}" rel="{{html .Rel}}"{{with .Title}} title="{{html .}}"{{end}}{{with .Type}} type="{{html .}}"{{end}}{{with .Hreflang}} hreflang="{{html .}}"{{end}}{{with .Name}} data-name="{{html .}}"{{end}}{{with .Profile}} data-profile="{{html .}}"{{end}}{{with .Deprecation}} data-deprecation="{{html .}}"{{end}}{{with .Method}} data-method="{{html .}}"{{end}}{{if .Templated}} data-templated="true"{{end}}>{{html .Title}}</a>
 	{{end}}
 	`
 )
 
 // Define a map of HTTP status codes to error messages.
 var httpErrors = map[int]string{
-	StatusOK:                  "OK",
-	StatusBadRequest:          "Bad Request",
-	StatusUnauthorized:        "Unauthorized",
-	StatusForbidden:           "Forbidden",
-	StatusNotFound:            "Not Found",
-	StatusMethodNotAllowed:    "Method Not Allowed",
-	StatusInternalServerError: "Internal Server Error",
+	StatusOK:                   "OK",
+	StatusBadRequest:           "Bad Request",
+	StatusUnauthorized:         "Unauthorized",
+	StatusForbidden:            "Forbidden",
+	StatusNotFound:             "Not Found",
+	StatusMethodNotAllowed:     "Method Not Allowed",
+	StatusNotAcceptable:        "Not Acceptable",
+	StatusConflict:             "Conflict",
+	StatusUnsupportedMediaType: "Unsupported Media Type",
+	StatusUnprocessableEntity:  "Unprocessable Entity",
+	StatusInternalServerError:  "Internal Server Error",
+	StatusServiceUnavailable:   "Service Unavailable",
 }
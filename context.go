@@ -12,19 +12,28 @@ import (
 type (
 	// Context describes the context of a request.
 	Context interface {
-		Request() *http.Request                        // The HTTP request.
-		Response() *Response                           // The HTTP response.
-		Resource() Resource                            // The resource.
-		SetResource(res Resource)                      // Set the resource.
-		AddParam(name, value string)                   // Set a parameter.
-		GetParamValue(name string) string              // Get a parameter.
-		GetParams() []Param                            // The parameters.
-		Path() string                                  // The path of the request.
-		Itsy() *Itsy                                   // The main framework instance.
-		WriteString(s string) error                    // Write a string to the response.
-		WriteHTML() error                              // Write the response as HTML.
-		SetTemplateRenderer(renderer TemplateRenderer) // Set the template renderer.
-		GetTemplateRenderer() TemplateRenderer         // Get the template renderer.
+		Request() *http.Request                             // The HTTP request.
+		Response() *Response                                // The HTTP response.
+		Resource() Resource                                 // The resource.
+		SetResource(res Resource)                           // Set the resource.
+		AddParam(name, value string)                        // Set a parameter.
+		GetParamValue(name string) string                   // Get a parameter.
+		GetParams() []Param                                 // The parameters.
+		Path() string                                       // The path of the request.
+		Itsy() *Itsy                                        // The main framework instance.
+		WriteString(s string) error                         // Write a string to the response.
+		WriteHTML() error                                   // Write the response as HTML.
+		Render(status int, value interface{}) error         // Write the value in the negotiated representation.
+		AddLink(href, rel string, opts ...LinkOption) error // Add a link to this response only.
+		Links() []Link                                      // The links added to this response.
+		SetLinkValue(name string, value interface{})        // Supply a value for links mapped with LinkValue.
+		LinkValue(name string) (interface{}, bool)          // Get a value supplied for links.
+		SetTemplateRenderer(renderer TemplateRenderer)      // Set the template renderer.
+		GetTemplateRenderer() TemplateRenderer              // Get the template renderer.
+		SpanContext() SpanContext                           // The trace context of the current span.
+		StartSpan(name string) *Span                        // Start a child of the current span.
+		Logger() *zap.Logger                                // The logger, annotated with the trace.
+		Client() *http.Client                               // An HTTP client that propagates the trace.
 	}
 	// TemplateRenderer is the interface that describes a template renderer.
 	TemplateRenderer interface {
@@ -47,6 +56,11 @@ type (
 		path             string
 		itsy             *Itsy
 		templateRenderer TemplateRenderer
+		span             *Span
+		links            []Link
+		linkValues       map[string]interface{}
+		embedding        *embedding
+		allowed          []Transition
 	}
 )
 
@@ -78,6 +92,45 @@ func (c *baseContext) GetTemplateRenderer() TemplateRenderer {
 	return c.templateRenderer
 }
 
+// SpanContext returns the trace context of the current span.
+func (c *baseContext) SpanContext() SpanContext {
+	if c.span == nil {
+		return SpanContext{}
+	}
+	return c.span.Context
+}
+
+// StartSpan starts a child of the current span, which becomes the current span until it is finished.
+// It returns nil if tracing is disabled.
+func (c *baseContext) StartSpan(name string) *Span {
+	if c.itsy.tracer == nil || c.span == nil {
+		return nil
+	}
+	parent := c.span
+	span := c.itsy.tracer.start(name, parent.Context)
+	c.span = span
+	span.onEnd = func() {
+		if c.span == span {
+			c.span = parent
+		}
+	}
+	return span
+}
+
+// Logger returns the logger, annotated with the trace and span IDs when tracing is enabled.
+func (c *baseContext) Logger() *zap.Logger {
+	return traceLogger(c.itsy.Logger, c.SpanContext())
+}
+
+// Client returns an HTTP client that propagates the trace context to outbound requests.
+func (c *baseContext) Client() *http.Client {
+	return &http.Client{Transport: &traceTransport{
+		base:   http.DefaultTransport,
+		tracer: c.itsy.tracer,
+		parent: c.SpanContext(),
+	}}
+}
+
 func (c *baseContext) GetParams() []Param {
 	return c.params
 }
@@ -96,6 +149,36 @@ func (c *baseContext) GetParamValue(name string) string {
 	return ""
 }
 
+// AddLink adds a link to this response only, leaving the resource's links unchanged.
+// The href may be a URI template and may point outside the application.
+func (c *baseContext) AddLink(href, rel string, opts ...LinkOption) error {
+	link, err := newLink(href, rel, opts...)
+	if err != nil {
+		return err
+	}
+	c.links = append(c.links, link)
+	return nil
+}
+
+// Links returns the links added to this response.
+func (c *baseContext) Links() []Link {
+	return c.links
+}
+
+// SetLinkValue supplies a value for the link variables mapped to name with LinkValue.
+func (c *baseContext) SetLinkValue(name string, value interface{}) {
+	if c.linkValues == nil {
+		c.linkValues = make(map[string]interface{})
+	}
+	c.linkValues[name] = value
+}
+
+// LinkValue returns the value supplied for name, if any.
+func (c *baseContext) LinkValue(name string) (interface{}, bool) {
+	value, ok := c.linkValues[name]
+	return value, ok
+}
+
 // WriteString writes a string to the response.
 func (c *baseContext) WriteString(s string) error {
 	r := c.Response()
@@ -106,7 +189,7 @@ func (c *baseContext) WriteString(s string) error {
 	}
 
 	if written != len(s) {
-		r.itsy.sendHTTPError(StatusInternalServerError, "Response length mismatch", r.Writer, r.itsy.Logger)
+		r.itsy.sendHTTPError(StatusInternalServerError, "Response length mismatch", r, r.itsy.Logger)
 		return errors.New("Response length mismatch")
 	}
 
@@ -115,8 +198,8 @@ func (c *baseContext) WriteString(s string) error {
 
 // WriteHTML writes the response as HTML.
 func (c *baseContext) WriteHTML() error {
-	originalWriter := c.Response().Writer
-	if originalWriter == nil {
+	originalWriter := c.Response()
+	if originalWriter.Writer == nil {
 		return errors.New("Response writer is nil")
 	}
 
@@ -131,7 +214,7 @@ func (c *baseContext) WriteHTML() error {
 	}
 
 	// Render the links using the standard link template.
-	links := c.Resource().Links()
+	links := append(append([]Link{}, c.Resource().Links()...), c.Links()...)
 	if len(links) > 0 {
 		// Render the links.
 		if err := renderer.RenderLinks(c, originalWriter, links); err != nil {
@@ -148,15 +231,8 @@ func (r *defaultTemplateRenderer) RenderTemplate(w io.Writer, c Context) error {
 }
 
 func (r *defaultTemplateRenderer) RenderLinks(c Context, w io.Writer, links []Link) error {
-	// Modify the href attributes to replace the placeholders with the corresponding parameter values.
-	for i, link := range links {
-		href := link.Href
-		href = link.re.ReplaceAllStringFunc(href, func(s string) string {
-			paramName := s[1:]
-			return c.GetParamValue(paramName)
-		})
-		links[i].Href = href
-	}
+	// Replace the placeholders in the href attributes with the corresponding parameter values.
+	links = resolveLinks(c, links)
 
 	// Parse the standard link template.
 	t, err := template.New("links").Parse(linkTemplate)
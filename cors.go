@@ -0,0 +1,147 @@
+package itsy
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type (
+	// CORSConfig configures the CORS middleware.
+	CORSConfig struct {
+		AllowOrigins     []string          // Allowed origins, exact, "*" or with a wildcard such as "https://*.example.com".
+		AllowOriginFunc  func(string) bool // Allows an origin that AllowOrigins doesn't.
+		AllowMethods     []string          // Methods allowed cross-origin, all registered methods if empty.
+		AllowHeaders     []string          // Request headers allowed cross-origin, the requested headers if empty.
+		ExposeHeaders    []string          // Response headers exposed to the client.
+		AllowCredentials bool              // Allow cookies and authorization headers, not with the "*" origin.
+		MaxAge           int               // How long a preflight response may be cached, in seconds.
+	}
+	// originPattern matches an origin against an allowed origin with an optional wildcard.
+	originPattern struct {
+		prefix   string
+		suffix   string
+		wildcard bool
+	}
+)
+
+// CORS creates a middleware that implements cross-origin resource sharing.
+// Preflight requests are answered with the methods registered on the requested resource.
+// CORS panics if credentials are allowed for any origin, since the allowed origins must be listed.
+func CORS(config CORSConfig) Middleware {
+	if config.AllowCredentials && containsOrigin(config.AllowOrigins, "*") {
+		panic("itsy: CORS credentials can't be allowed for the \"*\" origin")
+	}
+	patterns := make([]originPattern, 0, len(config.AllowOrigins))
+	for _, origin := range config.AllowOrigins {
+		patterns = append(patterns, newOriginPattern(origin))
+	}
+	allowMethods := make(map[string]bool, len(config.AllowMethods))
+	for _, method := range config.AllowMethods {
+		allowMethods[strings.ToUpper(method)] = true
+	}
+
+	return func(c Context, next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			req := c.Request()
+			header := c.Response().Header()
+			origin := req.Header.Get(HeaderOrigin)
+			preflight := isPreflight(req)
+
+			header.Add(HeaderVary, HeaderOrigin)
+			if preflight {
+				header.Add(HeaderVary, HeaderAccessControlRequestMethod)
+				header.Add(HeaderVary, HeaderAccessControlRequestHeaders)
+			}
+
+			// Not a cross-origin request, or one from a disallowed origin.
+			if origin == "" || !config.allows(patterns, origin) {
+				if preflight {
+					return optionsHandler(c)
+				}
+				return next(c)
+			}
+
+			if containsOrigin(config.AllowOrigins, "*") {
+				header.Set(HeaderAccessControlAllowOrigin, "*")
+			} else {
+				header.Set(HeaderAccessControlAllowOrigin, origin)
+			}
+			if config.AllowCredentials {
+				header.Set(HeaderAccessControlAllowCredentials, "true")
+			}
+
+			if !preflight {
+				if len(config.ExposeHeaders) > 0 {
+					header.Set(HeaderAccessControlExposeHeaders, strings.Join(config.ExposeHeaders, ", "))
+				}
+				return next(c)
+			}
+
+			// Answer the preflight from the resource's registered methods.
+			methods := make([]string, 0)
+			for _, method := range resourceMethods(c.Resource()) {
+				if len(allowMethods) == 0 || allowMethods[method] {
+					methods = append(methods, method)
+				}
+			}
+			header.Set(HeaderAccessControlAllowMethods, strings.Join(methods, ", "))
+
+			if len(config.AllowHeaders) > 0 {
+				header.Set(HeaderAccessControlAllowHeaders, strings.Join(config.AllowHeaders, ", "))
+			} else if requested := req.Header.Get(HeaderAccessControlRequestHeaders); requested != "" {
+				header.Set(HeaderAccessControlAllowHeaders, requested)
+			}
+			if config.MaxAge > 0 {
+				header.Set(HeaderAccessControlMaxAge, strconv.Itoa(config.MaxAge))
+			}
+
+			return optionsHandler(c)
+		}
+	}
+}
+
+// allows returns true if the origin is allowed.
+func (config CORSConfig) allows(patterns []originPattern, origin string) bool {
+	for _, p := range patterns {
+		if p.matches(origin) {
+			return true
+		}
+	}
+	return config.AllowOriginFunc != nil && config.AllowOriginFunc(origin)
+}
+
+// newOriginPattern creates a pattern from an allowed origin.
+func newOriginPattern(origin string) originPattern {
+	origin = strings.ToLower(origin)
+	if n := strings.IndexByte(origin, '*'); n >= 0 {
+		return originPattern{prefix: origin[:n], suffix: origin[n+1:], wildcard: true}
+	}
+	return originPattern{prefix: origin}
+}
+
+// matches returns true if the origin matches the pattern.
+func (p originPattern) matches(origin string) bool {
+	origin = strings.ToLower(origin)
+	if !p.wildcard {
+		return origin == p.prefix
+	}
+	return len(origin) >= len(p.prefix)+len(p.suffix) &&
+		strings.HasPrefix(origin, p.prefix) &&
+		strings.HasSuffix(origin, p.suffix)
+}
+
+// containsOrigin returns true if the origins contain the given origin.
+func containsOrigin(origins []string, origin string) bool {
+	for _, o := range origins {
+		if o == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// isPreflight returns true if the request is a CORS preflight request.
+func isPreflight(req *http.Request) bool {
+	return req.Method == OPTIONS && req.Header.Get(HeaderOrigin) != "" && req.Header.Get(HeaderAccessControlRequestMethod) != ""
+}
@@ -0,0 +1,96 @@
+package itsy
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCORSPreflight(t *testing.T) {
+	i := New()
+	i.Use(CORS(CORSConfig{
+		AllowOrigins:     []string{"https://*.example.com"},
+		AllowCredentials: true,
+		MaxAge:           600,
+	}))
+
+	r := i.Register("/orders/:id")
+	r.GET(func(c Context) error { return c.WriteString("order") })
+	r.PATCH(func(c Context) error { return c.WriteString("patched") })
+
+	// Send a preflight request from an allowed origin.
+	req := httptest.NewRequest(OPTIONS, "/orders/1", nil)
+	req.Header.Set(HeaderOrigin, "https://app.example.com")
+	req.Header.Set(HeaderAccessControlRequestMethod, PATCH)
+	req.Header.Set(HeaderAccessControlRequestHeaders, "Content-Type")
+	rr := httptest.NewRecorder()
+	i.ServeHTTP(rr, req)
+
+	if rr.Code != StatusNoContent {
+		t.Fatalf("Expected status %d, got %d", StatusNoContent, rr.Code)
+	}
+	for header, expected := range map[string]string{
+		HeaderAccessControlAllowOrigin:      "https://app.example.com",
+		HeaderAccessControlAllowMethods:     "GET, PATCH",
+		HeaderAccessControlAllowHeaders:     "Content-Type",
+		HeaderAccessControlAllowCredentials: "true",
+		HeaderAccessControlMaxAge:           "600",
+		HeaderAllow:                         "GET, HEAD, PATCH, OPTIONS",
+	} {
+		if got := rr.Header().Get(header); got != expected {
+			t.Errorf("Expected %s %q, got %q", header, expected, got)
+		}
+	}
+
+	// The handler is reachable with PATCH.
+	req = httptest.NewRequest(PATCH, "/orders/1", nil)
+	req.Header.Set(HeaderOrigin, "https://app.example.com")
+	rr = httptest.NewRecorder()
+	i.ServeHTTP(rr, req)
+	if rr.Body.String() != "patched" || rr.Header().Get(HeaderAccessControlAllowOrigin) != "https://app.example.com" {
+		t.Errorf("Unexpected response: %q %v", rr.Body.String(), rr.Header())
+	}
+}
+
+func TestCORSOrigins(t *testing.T) {
+	i := New()
+	i.Use(CORS(CORSConfig{
+		AllowOrigins:    []string{"https://exact.test"},
+		AllowOriginFunc: func(origin string) bool { return strings.HasSuffix(origin, ".internal") },
+		ExposeHeaders:   []string{"X-Request-Id"},
+	}))
+	i.Register("/").GET(func(c Context) error { return c.WriteString("root") })
+
+	for origin, allowed := range map[string]bool{
+		"https://exact.test":      true,
+		"https://EXACT.test":      true,
+		"http://svc.internal":     true,
+		"https://evil.test":       false,
+		"https://exact.test.evil": false,
+	} {
+		req := httptest.NewRequest(GET, "/", nil)
+		req.Header.Set(HeaderOrigin, origin)
+		rr := httptest.NewRecorder()
+		i.ServeHTTP(rr, req)
+
+		got := rr.Header().Get(HeaderAccessControlAllowOrigin) != ""
+		if got != allowed {
+			t.Errorf("Expected origin %q allowed=%v, got %v", origin, allowed, got)
+		}
+		if allowed && rr.Header().Get(HeaderAccessControlExposeHeaders) != "X-Request-Id" {
+			t.Errorf("Expected exposed headers for %q", origin)
+		}
+		if rr.Header().Get(HeaderVary) != HeaderOrigin {
+			t.Errorf("Expected Vary: Origin for %q", origin)
+		}
+	}
+}
+
+func TestCORSCredentialsForAnyOrigin(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected CORS to panic when credentials are allowed for any origin")
+		}
+	}()
+	CORS(CORSConfig{AllowOrigins: []string{"*"}, AllowCredentials: true})
+}
@@ -0,0 +1,151 @@
+package itsy
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// EmbedParam is the query parameter with which clients ask for linked resources to be embedded,
+	// as in ?embed=customer,items.
+	EmbedParam = "embed"
+	// DefaultEmbedDepth is how deep embedded resources embed their own, unless MaxEmbedDepth is set.
+	DefaultEmbedDepth = 2
+	// DefaultMaxEmbeds is how many resources a response embeds at most, unless MaxEmbeds is set.
+	DefaultMaxEmbeds = 32
+)
+
+type (
+	// embedding is the state of a sub-request that renders an embedded resource.
+	embedding struct {
+		depth  int             // How deep the resource is embedded, 1 for the resources of the response.
+		chain  []string        // The URLs of the representations embedding the resource, outermost first.
+		rep    *Representation // The representation the handler rendered.
+		budget *int            // How many more resources the response may embed.
+	}
+	// discardWriter is the response writer of sub-requests, which are rendered into representations.
+	discardWriter struct {
+		header http.Header
+	}
+)
+
+func (w *discardWriter) Header() http.Header         { return w.header }
+func (w *discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *discardWriter) WriteHeader(int)             {}
+
+// embedRels returns the relations whose linked resources are embedded in the representation:
+// those the resource declares, and at the top level those the client asks for with EmbedParam.
+func embedRels(c *baseContext, rep *Representation) []string {
+	rels := make([]string, 0)
+	if rep.Resource != nil {
+		rels = append(rels, resourceEmbeds(rep.Resource)...)
+	}
+	if c.embedding == nil {
+		for _, value := range c.Request().URL.Query()[EmbedParam] {
+			for _, rel := range strings.Split(value, ",") {
+				if rel = strings.TrimSpace(rel); rel != "" {
+					rels = append(rels, rel)
+				}
+			}
+		}
+	}
+	return rels
+}
+
+// embed resolves the linked resources with the embedded relations by invoking their GET handlers,
+// and adds their representations to rep. Links that would exceed the depth limit or the number of
+// embedded resources, or lead back to a representation that embeds this one, are left as links.
+func (c *baseContext) embed(rep *Representation) {
+	depth, chain := 1, []string{rep.Self}
+	var budget *int
+	if c.embedding != nil {
+		depth, chain = c.embedding.depth+1, append(append([]string{}, c.embedding.chain...), rep.Self)
+		budget = c.embedding.budget
+	} else {
+		budget = new(int)
+		if *budget = c.itsy.MaxEmbeds; *budget == 0 {
+			*budget = DefaultMaxEmbeds
+		}
+	}
+	maxDepth := c.itsy.MaxEmbedDepth
+	if maxDepth == 0 {
+		maxDepth = DefaultEmbedDepth
+	}
+	if depth > maxDepth {
+		return
+	}
+
+	embedded := make(map[string]bool)
+	for _, rel := range embedRels(c, rep) {
+		if embedded[rel] {
+			continue
+		}
+		embedded[rel] = true
+		for _, link := range rep.Hypermedia.Links {
+			if link.Rel != rel || link.Templated || external(link.Href) {
+				continue
+			}
+			if path, _, _ := strings.Cut(link.Href, "?"); inChain(chain, path) {
+				c.Logger().Debug("Embedding cycle skipped", zap.String("rel", rel), zap.String("href", link.Href))
+				continue
+			}
+			if *budget <= 0 {
+				c.Logger().Debug("Embedding limit reached", zap.String("rel", rel), zap.String("href", link.Href))
+				return
+			}
+			*budget--
+			if sub := c.subRequest(link.Href, &embedding{depth: depth, chain: chain, budget: budget}); sub != nil {
+				rep.Embedded[rel] = append(rep.Embedded[rel], sub)
+			}
+		}
+	}
+}
+
+// subRequest invokes the GET handler of the resource at href within the request and returns the
+// representation it renders, or nil if it fails or renders none.
+func (c *baseContext) subRequest(href string, e *embedding) *Representation {
+	target, err := url.Parse(href)
+	if err != nil {
+		return nil
+	}
+	req := c.Request().Clone(c.Request().Context())
+	req.Method = GET
+	req.URL = c.Request().URL.ResolveReference(target)
+	req.RequestURI = req.URL.RequestURI()
+	req.Body, req.ContentLength = http.NoBody, 0
+	req.Header.Del(HeaderContentType)
+
+	res := NewResponse(&discardWriter{header: make(http.Header)}, c.itsy)
+	sub := newBaseContext(req, res, nil, req.URL.Path, c.itsy)
+	sub.span, sub.embedding = c.span, e
+
+	n := c.itsy.processRouteSegments(sub, req.URL.Path)
+	if n == nil || n.resource == nil || n.resource.Handler(GET) == nil {
+		return nil
+	}
+	sub.SetResource(n.resource)
+	if err := c.itsy.callHandler(c.itsy.guardTransitions(n.resource.Handler(GET)), sub); err != nil || e.rep == nil || res.StatusCode >= StatusBadRequest {
+		c.Logger().Debug("Embedded resource not rendered", zap.String("href", href), zap.Int("status", res.StatusCode))
+		return nil
+	}
+	return e.rep
+}
+
+// embedded reports whether the context is that of a sub-request rendering an embedded resource.
+func embedded(c Context) bool {
+	bc, ok := c.(*baseContext)
+	return ok && bc.embedding != nil
+}
+
+// inChain reports whether the path is in the chain.
+func inChain(chain []string, path string) bool {
+	for _, self := range chain {
+		if self == path {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,162 @@
+package itsy
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func newEmbedTestItsy() *Itsy {
+	i := New()
+	customers := map[string]string{"1": "Ann", "2": "Bob"}
+	orders := map[string]string{"7": "1", "8": "2"}
+
+	order := i.Register("/orders/:id")
+	order.GET(func(c Context) error {
+		customer, ok := orders[c.GetParamValue("id")]
+		if !ok {
+			return NewHTTPError(StatusNotFound, "No such order")
+		}
+		c.SetLinkValue("customer", customer)
+		return c.Render(StatusOK, map[string]string{"id": c.GetParamValue("id")})
+	})
+	order.Link("/customers/{id}", "customer", LinkValue("id", "customer"))
+	order.Link("/orders/:id/items", "items")
+	order.(Embedder).Embed("customer")
+
+	customer := i.Register("/customers/:id")
+	customer.GET(func(c Context) error {
+		for id, customer := range orders {
+			if customer == c.GetParamValue("id") {
+				c.AddLink("/orders/"+id, "orders")
+			}
+		}
+		return c.Render(StatusOK, map[string]string{"name": customers[c.GetParamValue("id")]})
+	})
+	customer.Link("/customers/:id/address", "address")
+	customer.(Embedder).Embed("orders")
+	customer.(Embedder).Embed("address")
+
+	i.Register("/customers/:id/address").GET(func(c Context) error {
+		return c.Render(StatusOK, map[string]string{"city": "Paris"})
+	})
+	i.Register("/orders/:id/items").GET(func(c Context) error {
+		return c.Render(StatusOK, []string{"tea"})
+	})
+	return i
+}
+
+func TestEmbed(t *testing.T) {
+	i := newEmbedTestItsy()
+	get := func(target, accept string) string {
+		req := httptest.NewRequest(GET, target, nil)
+		req.Header.Set(HeaderAccept, accept)
+		rr := httptest.NewRecorder()
+		i.ServeHTTP(rr, req)
+		if rr.Code != StatusOK {
+			t.Fatalf("Expected status %d for %s, got %d", StatusOK, target, rr.Code)
+		}
+		return rr.Body.String()
+	}
+
+	// The customer is embedded with its address. Its link back to the order is a cycle and stays a link.
+	doc, err := DecodeHAL(strings.NewReader(get("/orders/7", MIMEAppHALJSON)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := doc.Embedded["items"]; ok {
+		t.Error("Expected the items not to be embedded")
+	}
+	customers := doc.Embedded["customer"]
+	if len(customers) != 1 || customers[0].Properties["name"] != "Ann" {
+		t.Fatalf("Expected the embedded customer, got %+v", doc.Embedded)
+	}
+	if self, _ := customers[0].Link("self"); self.Href != "/customers/1" {
+		t.Errorf("Expected the embedded customer's self link, got %q", self.Href)
+	}
+	if address := customers[0].Embedded["address"]; len(address) != 1 || address[0].Properties["city"] != "Paris" {
+		t.Errorf("Expected the embedded address, got %+v", customers[0].Embedded)
+	}
+	if _, ok := customers[0].Embedded["orders"]; ok {
+		t.Error("Expected the order not to embed itself")
+	}
+	if orders, _ := customers[0].Link("orders"); orders.Href != "/orders/7" {
+		t.Errorf("Expected the link back to the order, got %q", orders.Href)
+	}
+
+	// Clients can ask for more relations to be embedded.
+	var entity SirenEntity
+	if err := json.Unmarshal([]byte(get("/orders/7?embed=items,customer", MIMEAppSirenJSON)), &entity); err != nil {
+		t.Fatal(err)
+	}
+	rels := make([]string, 0)
+	for _, sub := range entity.Entities {
+		rels = append(rels, sub.Rel[0])
+	}
+	if got := strings.Join(rels, ","); got != "customer,items" {
+		t.Errorf("Expected the customer and items sub-entities, got %q", got)
+	}
+
+	// HTML embeds partials.
+	if body := get("/orders/7", MIMETextHTML); !strings.Contains(body, `<section rel="customer">`) || !strings.Contains(body, "Ann") || !strings.Contains(body, "Paris") {
+		t.Errorf("Expected the embedded customer section, got %s", body)
+	}
+}
+
+func TestEmbedDepth(t *testing.T) {
+	i := newEmbedTestItsy()
+	i.MaxEmbedDepth = 1
+
+	req := httptest.NewRequest(GET, "/orders/8", nil)
+	req.Header.Set(HeaderAccept, MIMEAppHALJSON)
+	rr := httptest.NewRecorder()
+	i.ServeHTTP(rr, req)
+	doc, err := DecodeHAL(rr.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	customers := doc.Embedded["customer"]
+	if len(customers) != 1 || customers[0].Properties["name"] != "Bob" {
+		t.Fatalf("Expected the embedded customer, got %+v", doc.Embedded)
+	}
+	if len(customers[0].Embedded) != 0 {
+		t.Errorf("Expected nothing embedded beyond the depth limit, got %+v", customers[0].Embedded)
+	}
+	if address, _ := customers[0].Link("address"); address.Href != "/customers/2/address" {
+		t.Errorf("Expected the address link, got %q", address.Href)
+	}
+}
+
+func TestEmbedLimit(t *testing.T) {
+	i := newEmbedTestItsy()
+	i.MaxEmbeds = 1
+	core, logs := observer.New(zap.DebugLevel)
+	i.Logger = zap.New(core)
+	i.Resource("/orders/:id").Link("/orders/99", "related")
+
+	// Only the first linked resource is embedded, the rest stay links.
+	req := httptest.NewRequest(GET, "/orders/7?embed=items,related", nil)
+	req.Header.Set(HeaderAccept, MIMEAppHALJSON)
+	rr := httptest.NewRecorder()
+	i.ServeHTTP(rr, req)
+	doc, err := DecodeHAL(rr.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Embedded) != 1 || len(doc.Embedded["customer"]) != 1 || len(doc.Embedded["customer"][0].Embedded) != 0 {
+		t.Errorf("Expected only the customer to be embedded, got %+v", doc.Embedded)
+	}
+
+	// Embedded resources that fail are logged at debug level.
+	i.MaxEmbeds = 0
+	req = httptest.NewRequest(GET, "/orders/7?embed=related", nil)
+	req.Header.Set(HeaderAccept, MIMEAppHALJSON)
+	i.ServeHTTP(httptest.NewRecorder(), req)
+	if failed := logs.FilterMessage("Handler failed"); failed.Len() != 1 || failed.All()[0].Level != zap.DebugLevel {
+		t.Errorf("Expected the missing order to be logged at debug level, got %+v", failed.All())
+	}
+}
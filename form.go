@@ -0,0 +1,282 @@
+package itsy
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+)
+
+// MIMEMultipartForm is the media type of multipart form submissions.
+const MIMEMultipartForm = "multipart/form-data"
+
+type (
+	// Form is a form that submits to a resource, such as a search form or a form to create an item.
+	Form struct {
+		template *URITemplate
+		Name     string  `json:"name"`              // The name of the form, unique within the resource.
+		Title    string  `json:"title,omitempty"`   // A human-readable label of the form.
+		Action   string  `json:"action"`            // The href of the resource the form submits to, which may be a URI template.
+		Method   string  `json:"method"`            // The method of the submission.
+		Enctype  string  `json:"enctype,omitempty"` // The media type of the submission.
+		Fields   []Field `json:"fields,omitempty"`  // The fields of the form.
+	}
+	// FieldError describes a submitted value that doesn't satisfy its field.
+	FieldError struct {
+		Field   string // The name of the field.
+		Problem string // What is wrong with the value.
+	}
+)
+
+// Error returns the field and its problem.
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Problem
+}
+
+// resolveForms returns copies of the forms with their actions expanded with the request's parameter values.
+func resolveForms(c Context, forms []Form) []Form {
+	values := paramValues(c)
+	resolved := make([]Form, 0, len(forms))
+	for _, form := range forms {
+		if form.template != nil {
+			form.Action, _ = form.template.PartialExpand(values)
+		}
+		resolved = append(resolved, form)
+	}
+	return resolved
+}
+
+// formActionTemplate returns the template of a form's action without its query and fragment
+// expressions, since the form's fields supply the query.
+func formActionTemplate(t *URITemplate) *URITemplate {
+	action := &URITemplate{}
+	var raw strings.Builder
+	for _, part := range t.parts {
+		switch {
+		case part.op == nil:
+			raw.WriteString(part.literal)
+		case part.op.op == "?" || part.op.op == "&" || part.op.op == "#":
+			continue
+		default:
+			specs := make([]string, 0, len(part.vars))
+			for _, v := range part.vars {
+				specs = append(specs, v.spec())
+			}
+			raw.WriteString("{" + part.op.op + strings.Join(specs, ",") + "}")
+		}
+		action.parts = append(action.parts, part)
+	}
+	action.raw = raw.String()
+	return action
+}
+
+// allForms returns the actions as forms, followed by the forms.
+func (h *Hypermedia) allForms() []Form {
+	forms := make([]Form, 0, len(h.Actions)+len(h.Forms))
+	for _, action := range h.Actions {
+		forms = append(forms, Form{
+			Name:    action.Name,
+			Title:   action.Title,
+			Action:  action.Href,
+			Method:  action.Method,
+			Enctype: action.Type,
+			Fields:  action.Fields,
+		})
+	}
+	return append(forms, h.Forms...)
+}
+
+// formTemplate renders forms as HTML. Browsers only submit GET and POST, so other methods
+// are posted with the intended method in a data-method attribute.
+var formTemplate = template.Must(template.New("forms").Parse(`{{range .}}<form name="{{html .Name}}" action="{{html .Action}}" method="{{if eq .Method "GET"}}get{{else}}post{{end}}"{{if not (or (eq .Method "GET") (eq .Method "POST"))}} data-method="{{html .Method}}"{{end}}{{with .Enctype}} enctype="{{html .}}"{{end}}>
+{{range .Fields}}<label>{{html (or .Title .Name)}} {{if .Options}}<select name="{{html .Name}}"{{if .Required}} required{{end}}>{{$value := print .Value}}{{range .Options}}<option value="{{html .Value}}"{{if eq $value .Value}} selected{{end}}>{{html (or .Title .Value)}}</option>{{end}}</select>{{else}}<input name="{{html .Name}}" type="{{html (or .Type "text")}}"{{with .Value}} value="{{html .}}"{{end}}{{if .Required}} required{{end}}{{with .Pattern}} pattern="{{html .}}"{{end}}{{with .Min}} min="{{.}}"{{end}}{{with .Max}} max="{{.}}"{{end}}{{with .MinLength}} minlength="{{.}}"{{end}}{{with .MaxLength}} maxlength="{{.}}"{{end}}>{{end}}</label>
+{{end}}<button type="submit">{{html (or .Title .Name)}}</button>
+</form>
+{{end}}`))
+
+// writeHTMLForms writes the forms as HTML form elements.
+func writeHTMLForms(w io.Writer, forms []Form) error {
+	return formTemplate.Execute(w, forms)
+}
+
+// ValidateFields checks submitted values against the fields and returns the problems joined, or nil.
+// Fields without a value are only checked for being required.
+func ValidateFields(fields []Field, values url.Values) error {
+	var errs []error
+	for _, field := range fields {
+		submitted := make([]string, 0, len(values[field.Name]))
+		for _, value := range values[field.Name] {
+			if value != "" {
+				submitted = append(submitted, value)
+			}
+		}
+		if len(submitted) == 0 {
+			if field.Required {
+				errs = append(errs, &FieldError{Field: field.Name, Problem: "is required"})
+			}
+			continue
+		}
+		for _, value := range submitted {
+			if problem := field.problem(value); problem != "" {
+				errs = append(errs, &FieldError{Field: field.Name, Problem: problem})
+				break
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// compileFields returns copies of the fields with their patterns compiled, or an error
+// if a pattern is not a valid regular expression.
+func compileFields(fields []Field) ([]Field, error) {
+	compiled := make([]Field, len(fields))
+	for n, field := range fields {
+		if field.Pattern != "" {
+			pattern, err := regexp.Compile("^(?:" + field.Pattern + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("field %s: invalid pattern: %w", field.Name, err)
+			}
+			field.pattern = pattern
+		}
+		compiled[n] = field
+	}
+	return compiled, nil
+}
+
+// problem describes why a value doesn't satisfy the field, or returns "".
+func (f Field) problem(value string) string {
+	if len(f.Options) > 0 {
+		allowed := make([]string, len(f.Options))
+		for n, option := range f.Options {
+			if option.Value == value {
+				return ""
+			}
+			allowed[n] = option.Value
+		}
+		return "must be one of " + strings.Join(allowed, ", ")
+	}
+
+	switch f.Type {
+	case "number", "range":
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "must be a number"
+		}
+		if f.Min != nil && number < *f.Min {
+			return "must be at least " + strconv.FormatFloat(*f.Min, 'f', -1, 64)
+		}
+		if f.Max != nil && number > *f.Max {
+			return "must be at most " + strconv.FormatFloat(*f.Max, 'f', -1, 64)
+		}
+	case "email":
+		if address, err := mail.ParseAddress(value); err != nil || address.Name != "" {
+			return "must be an email address"
+		}
+	case "url":
+		if u, err := url.ParseRequestURI(value); err != nil || u.Scheme == "" {
+			return "must be a URL"
+		}
+	case "date":
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			return "must be a date"
+		}
+	}
+
+	length := utf8.RuneCountInString(value)
+	if f.MinLength > 0 && length < f.MinLength {
+		return fmt.Sprintf("must be at least %d characters long", f.MinLength)
+	}
+	if f.MaxLength > 0 && length > f.MaxLength {
+		return fmt.Sprintf("must be at most %d characters long", f.MaxLength)
+	}
+	if f.Pattern != "" {
+		pattern := f.pattern
+		if pattern == nil {
+			// The field wasn't declared by Form or Transition, which compile its pattern.
+			var err error
+			if pattern, err = regexp.Compile("^(?:" + f.Pattern + ")$"); err != nil {
+				return "must match " + f.Pattern
+			}
+		}
+		if !pattern.MatchString(value) {
+			return "must match " + f.Pattern
+		}
+	}
+	return ""
+}
+
+// BindForm parses the submission of a form to the request's resource and validates it against
+// the form's fields: those of the action declared for the request's method, or else those of a form
+// of any resource that submits to this one with the method. Missing values are filled with the
+// fields' defaults. It returns a 415 HTTPError for bodies that aren't forms and a 422 HTTPError
+// listing the problems of invalid submissions.
+func BindForm(c Context) (url.Values, error) {
+	req := c.Request()
+	var values url.Values
+	if req.Method == GET || req.Method == HEAD {
+		values = req.URL.Query()
+	} else {
+		switch mediaType(req.Header.Get(HeaderContentType)) {
+		case MIMEAppForm:
+			if err := req.ParseForm(); err != nil {
+				return nil, NewHTTPError(StatusBadRequest, "Malformed form")
+			}
+		case MIMEMultipartForm:
+			if err := req.ParseMultipartForm(32 << 20); err != nil {
+				return nil, NewHTTPError(StatusBadRequest, "Malformed form")
+			}
+		default:
+			return nil, NewHTTPError(StatusUnsupportedMediaType, "Expected "+MIMEAppForm+" or "+MIMEMultipartForm)
+		}
+		values = req.PostForm
+	}
+
+	fields := submissionFields(c)
+	for _, field := range fields {
+		if values.Get(field.Name) == "" && field.Value != nil {
+			values.Set(field.Name, fmt.Sprint(field.Value))
+		}
+	}
+	if err := ValidateFields(fields, values); err != nil {
+		problems := make([]string, 0)
+		for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+			problems = append(problems, e.Error())
+		}
+		return nil, NewHTTPError(StatusUnprocessableEntity, strings.Join(problems, "; "))
+	}
+	return values, nil
+}
+
+// submissionFields returns the fields of the form submitted with the request.
+func submissionFields(c Context) []Field {
+	resource := c.Resource()
+	if resource == nil {
+		return nil
+	}
+	method := c.Request().Method
+	if method == HEAD {
+		method = GET
+	}
+	for _, action := range resource.Hypermedia().Actions {
+		if action.Method == method {
+			return action.Fields
+		}
+	}
+
+	i := resource.Itsy()
+	for _, path := range i.resourcePaths() {
+		for _, form := range i.resources[path].Hypermedia().Forms {
+			if form.Method == method && !external(form.Action) && i.linkTarget(form.Action) == resource {
+				return form.Fields
+			}
+		}
+	}
+	return nil
+}
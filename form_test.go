@@ -0,0 +1,223 @@
+package itsy
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func newFormTestItsy(t *testing.T) *Itsy {
+	i := New()
+	one, hundred := 1.0, 100.0
+	orderFields := []Field{
+		{Name: "product", Title: "Product", Required: true, Options: []FieldOption{{Value: "tea", Title: "Tea"}, {Value: "coffee"}}},
+		{Name: "quantity", Title: "Quantity", Type: "number", Value: 1, Min: &one, Max: &hundred},
+		{Name: "email", Type: "email", Required: true},
+		{Name: "note", MaxLength: 10, Pattern: "[a-z ]*"},
+	}
+
+	i.Register("/users").GET(func(c Context) error {
+		values, err := BindForm(c)
+		if err != nil {
+			return err
+		}
+		return c.Render(StatusOK, map[string]string{"q": values.Get("q")})
+	})
+	users := i.Register("/users/:id")
+	users.GET(func(c Context) error { return c.Render(StatusOK, map[string]string{"id": c.GetParamValue("id")}) })
+	if err := users.(FormProvider).Form(Form{Name: "order", Title: "Order", Action: "/users/:id/orders", Method: POST, Fields: orderFields}); err != nil {
+		t.Fatal(err)
+	}
+	if err := users.(FormProvider).Form(Form{Name: "search", Action: "/users{?q}", Fields: []Field{{Name: "q", MinLength: 2}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	i.Register("/users/:id/orders").POST(func(c Context) error {
+		values, err := BindForm(c)
+		if err != nil {
+			return err
+		}
+		return c.Render(StatusOK, map[string]string{"product": values.Get("product"), "quantity": values.Get("quantity")})
+	})
+	return i
+}
+
+func TestFormRepresentations(t *testing.T) {
+	i := newFormTestItsy(t)
+	get := func(accept string) string {
+		req := httptest.NewRequest(GET, "/users/7", nil)
+		req.Header.Set(HeaderAccept, accept)
+		rr := httptest.NewRecorder()
+		i.ServeHTTP(rr, req)
+		if rr.Code != StatusOK {
+			t.Fatalf("Expected status %d for %s, got %d", StatusOK, accept, rr.Code)
+		}
+		return rr.Body.String()
+	}
+
+	body := get(MIMETextHTML)
+	for _, expected := range []string{
+		`<form name="order" action="/users/7/orders" method="post" enctype="application/x-www-form-urlencoded">`,
+		`<label>Product <select name="product" required><option value="tea">Tea</option><option value="coffee">coffee</option></select></label>`,
+		`<label>Quantity <input name="quantity" type="number" value="1" min="1" max="100"></label>`,
+		`<label>email <input name="email" type="email" required></label>`,
+		`<label>note <input name="note" type="text" pattern="[a-z ]*" maxlength="10"></label>`,
+		`<button type="submit">Order</button>`,
+		`<form name="search" action="/users" method="get">`,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected the HTML to contain %s, got %s", expected, body)
+		}
+	}
+
+	var entity SirenEntity
+	if err := json.Unmarshal([]byte(get(MIMEAppSirenJSON)), &entity); err != nil {
+		t.Fatal(err)
+	}
+	order, ok := entity.Action("order")
+	if !ok || order.Href != "/users/7/orders" || order.Method != POST || order.Type != MIMEAppForm || len(order.Fields) != 4 {
+		t.Errorf("Unexpected order action: %+v", order)
+	}
+	if product := order.Fields[0]; !product.Required || len(product.Options) != 2 || product.Options[0].Title != "Tea" {
+		t.Errorf("Unexpected product field: %+v", product)
+	}
+	if search, ok := entity.Action("search"); !ok || search.Href != "/users" || search.Method != GET {
+		t.Errorf("Unexpected search action: %+v", search)
+	}
+
+	doc, err := DecodeHAL(strings.NewReader(get(MIMEAppHALJSON)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	template, ok := doc.Templates["default"]
+	if !ok || template.Title != "Order" || template.Method != POST || template.Target != "/users/7/orders" || template.ContentType != MIMEAppForm {
+		t.Fatalf("Unexpected default template: %+v", doc.Templates)
+	}
+	product, quantity := template.Properties[0], template.Properties[1]
+	if product.Prompt != "Product" || !product.Required || product.Options == nil || product.Options.Inline[1] != (HALOption{Prompt: "coffee", Value: "coffee"}) {
+		t.Errorf("Unexpected product property: %+v", product)
+	}
+	if quantity.Type != "number" || *quantity.Min != 1 || *quantity.Max != 100 || quantity.Value != 1.0 {
+		t.Errorf("Unexpected quantity property: %+v", quantity)
+	}
+	if search, ok := doc.Templates["search"]; !ok || search.Method != GET || search.Target != "/users" {
+		t.Errorf("Unexpected search template: %+v", search)
+	}
+}
+
+func TestBindForm(t *testing.T) {
+	i := newFormTestItsy(t)
+	submit := func(method, target, contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set(HeaderAccept, MIMEAppJSON)
+		if contentType != "" {
+			req.Header.Set(HeaderContentType, contentType)
+		}
+		rr := httptest.NewRecorder()
+		i.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// A valid submission is bound, with defaults for missing values.
+	rr := submit(POST, "/users/7/orders", MIMEAppForm, "product=tea&email=ann@example.com&note=for+me")
+	if rr.Code != StatusOK || !strings.Contains(rr.Body.String(), `"quantity":"1"`) {
+		t.Errorf("Expected the order with the default quantity, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	// Clients that send no Accept header can submit too.
+	req := httptest.NewRequest(POST, "/users/7/orders", strings.NewReader("product=coffee&email=bob@example.com"))
+	req.Header.Set(HeaderContentType, MIMEAppForm)
+	rr = httptest.NewRecorder()
+	i.ServeHTTP(rr, req)
+	if rr.Code != StatusOK || !strings.Contains(rr.Body.String(), "coffee") {
+		t.Errorf("Expected the order without an Accept header, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	// Invalid submissions are rejected with every problem.
+	rr = submit(POST, "/users/7/orders", MIMEAppForm, "product=juice&quantity=500&email=ann&note=NOT+LOWERCASE")
+	if rr.Code != StatusUnprocessableEntity {
+		t.Fatalf("Expected status %d, got %d", StatusUnprocessableEntity, rr.Code)
+	}
+	for _, problem := range []string{
+		"product: must be one of tea, coffee",
+		"quantity: must be at most 100",
+		"email: must be an email address",
+		"note: must be at most 10 characters long",
+	} {
+		if !strings.Contains(rr.Body.String(), problem) {
+			t.Errorf("Expected the problem %q, got %s", problem, rr.Body.String())
+		}
+	}
+
+	// Other bodies are unsupported.
+	if rr = submit(POST, "/users/7/orders", MIMEAppJSON, `{"product":"tea"}`); rr.Code != StatusUnsupportedMediaType {
+		t.Errorf("Expected status %d, got %d", StatusUnsupportedMediaType, rr.Code)
+	}
+
+	// GET forms are validated from the query.
+	if rr = submit(GET, "/users?q=a", "", ""); rr.Code != StatusUnprocessableEntity || !strings.Contains(rr.Body.String(), "q: must be at least 2 characters long") {
+		t.Errorf("Expected the short query to be rejected, got %d %s", rr.Code, rr.Body.String())
+	}
+	if rr = submit(GET, "/users?q=ann", "", ""); rr.Code != StatusOK {
+		t.Errorf("Expected status %d, got %d", StatusOK, rr.Code)
+	}
+}
+
+func TestValidateFields(t *testing.T) {
+	fields := []Field{
+		{Name: "name", Required: true},
+		{Name: "site", Type: "url"},
+		{Name: "born", Type: "date"},
+		{Name: "age", Type: "number"},
+	}
+	err := ValidateFields(fields, url.Values{"site": {"example"}, "born": {"2024-02-30"}, "age": {"old"}})
+	var problems []string
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var fieldErr *FieldError
+		if !errors.As(e, &fieldErr) {
+			t.Fatalf("Expected a FieldError, got %T", e)
+		}
+		problems = append(problems, fieldErr.Error())
+	}
+	expected := "name: is required, site: must be a URL, born: must be a date, age: must be a number"
+	if got := strings.Join(problems, ", "); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+
+	if err := ValidateFields(fields, url.Values{"name": {"Ann"}, "site": {"https://example.com"}, "born": {"2024-02-29"}, "age": {"3"}}); err != nil {
+		t.Errorf("Expected no problems, got %v", err)
+	}
+}
+
+func TestFormInvalidPattern(t *testing.T) {
+	i := New()
+	orders := i.Register("/orders/:id")
+	field := Field{Name: "code", Pattern: "[a-z"}
+	if err := orders.(FormProvider).Form(Form{Name: "search", Action: "/orders", Fields: []Field{field}}); err == nil || !strings.Contains(err.Error(), "field code: invalid pattern") {
+		t.Errorf("Expected the form's invalid pattern to be reported, got %v", err)
+	}
+	orders.(ActionDescriber).Action(POST, "update", field)
+	if err := i.Validate(); err == nil || !strings.Contains(err.Error(), "/orders/:id: action update: field code: invalid pattern") {
+		t.Errorf("Expected the action's invalid pattern to be reported, got %v", err)
+	}
+	if err := orders.(Stateful).Transition(Transition{Name: "ship", Fields: []Field{field}}); err == nil || !strings.Contains(err.Error(), "field code: invalid pattern") {
+		t.Errorf("Expected the transition's invalid pattern to be reported, got %v", err)
+	}
+}
+
+func TestFormsStrictLinks(t *testing.T) {
+	i := newFormTestItsy(t)
+	i.StrictLinks = true
+	root := i.Register("/")
+	root.GET(func(c Context) error { return nil })
+	root.Link("/users/{id}", "user", LinkTemplated())
+	i.Resource("/users").Link("/", "home")
+
+	// The users and orders are reached through the forms.
+	if err := i.validateLinks(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
@@ -1,10 +1,14 @@
 module github.com/zachmdsi/itsy
 
-go 1.20
+go 1.24.0
 
-require go.uber.org/zap v1.24.0
+require (
+	go.uber.org/zap v1.24.0
+	golang.org/x/net v0.50.0
+)
 
 require (
 	go.uber.org/atomic v1.11.0 // indirect
 	go.uber.org/multierr v1.11.0 // indirect
+	golang.org/x/text v0.34.0 // indirect
 )
@@ -0,0 +1,163 @@
+package itsy
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// UnnamedCondition is the condition of a graph edge for a link declared with LinkWhen.
+	UnnamedCondition = "conditional"
+	// MIMETextGraphviz is the content type of the graph in the DOT language.
+	MIMETextGraphviz = "text/vnd.graphviz; charset=utf-8"
+)
+
+type (
+	// Graph is the link graph of the resources, for reviewing how clients navigate the API.
+	Graph struct {
+		Nodes []GraphNode `json:"nodes"` // The resources, ordered by path.
+		Edges []GraphEdge `json:"edges"` // The links, ordered by linking resource and declaration.
+	}
+	// GraphNode is a resource of the graph.
+	GraphNode struct {
+		Path    string   `json:"path"`    // The path of the resource.
+		Methods []string `json:"methods"` // The methods the resource has handlers for.
+	}
+	// GraphEdge is a link of the graph.
+	GraphEdge struct {
+		From      string `json:"from"`                // The path of the linking resource.
+		To        string `json:"to"`                  // The path of the linked resource, or the href if it isn't a resource.
+		Href      string `json:"href"`                // The href of the link.
+		Rel       string `json:"rel"`                 // The relation of the link.
+		Method    string `json:"method,omitempty"`    // The method to follow the link with, if not GET.
+		Condition string `json:"condition,omitempty"` // The name of the condition the link is rendered under, if any.
+	}
+)
+
+// Graph returns the link graph of the registered resources.
+func (i *Itsy) Graph() *Graph {
+	g := &Graph{Nodes: make([]GraphNode, 0), Edges: make([]GraphEdge, 0)}
+	for _, path := range i.resourcePaths() {
+		resource := i.resources[path]
+		g.Nodes = append(g.Nodes, GraphNode{Path: path, Methods: resourceMethods(resource)})
+		for _, link := range resource.Links() {
+			edge := GraphEdge{From: path, To: link.Href, Href: link.Href, Rel: link.Rel, Method: link.Method}
+			if target := i.linkTarget(link.Href); target != nil && !external(link.Href) {
+				edge.To = target.Path()
+			}
+			if link.condition != nil {
+				edge.Condition = link.condition.name
+				if edge.Condition == "" {
+					edge.Condition = UnnamedCondition
+				}
+			}
+			g.Edges = append(g.Edges, edge)
+		}
+	}
+	return g
+}
+
+// DOT returns the graph in the Graphviz DOT language. Conditional links are dashed,
+// and link targets that aren't resources are drawn as plain text.
+func (g *Graph) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph itsy {\n")
+	b.WriteString("\tnode [shape=box];\n")
+	for _, node := range g.Nodes {
+		fmt.Fprintf(&b, "\t%s [label=%s];\n", strconv.Quote(node.Path), strconv.Quote(node.label("\n")))
+	}
+	for _, target := range g.foreignTargets() {
+		fmt.Fprintf(&b, "\t%s [shape=plaintext];\n", strconv.Quote(target))
+	}
+	for _, edge := range g.Edges {
+		attrs := "label=" + strconv.Quote(edge.label())
+		if edge.Condition != "" {
+			attrs += ", style=dashed"
+		}
+		fmt.Fprintf(&b, "\t%s -> %s [%s];\n", strconv.Quote(edge.From), strconv.Quote(edge.To), attrs)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// Mermaid returns the graph as a Mermaid flowchart. Conditional links are dotted,
+// and link targets that aren't resources are drawn as rounded nodes.
+func (g *Graph) Mermaid() string {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	ids := make(map[string]string)
+	for n, node := range g.Nodes {
+		ids[node.Path] = "n" + strconv.Itoa(n)
+		fmt.Fprintf(&b, "\t%s[\"%s\"]\n", ids[node.Path], mermaidText(node.label("<br/>")))
+	}
+	for n, target := range g.foreignTargets() {
+		ids[target] = "x" + strconv.Itoa(n)
+		fmt.Fprintf(&b, "\t%s(\"%s\")\n", ids[target], mermaidText(target))
+	}
+	for _, edge := range g.Edges {
+		arrow := "-->"
+		if edge.Condition != "" {
+			arrow = "-.->"
+		}
+		fmt.Fprintf(&b, "\t%s %s|\"%s\"| %s\n", ids[edge.From], arrow, mermaidText(edge.label()), ids[edge.To])
+	}
+	return b.String()
+}
+
+// foreignTargets returns the targets of edges that aren't resources of the graph, in order of appearance.
+func (g *Graph) foreignTargets() []string {
+	nodes := make(map[string]bool, len(g.Nodes))
+	for _, node := range g.Nodes {
+		nodes[node.Path] = true
+	}
+	targets := make([]string, 0)
+	for _, edge := range g.Edges {
+		if !nodes[edge.To] {
+			nodes[edge.To] = true
+			targets = append(targets, edge.To)
+		}
+	}
+	return targets
+}
+
+// label returns the path of the node and its methods on the next line.
+func (node GraphNode) label(lineBreak string) string {
+	if len(node.Methods) == 0 {
+		return node.Path
+	}
+	return node.Path + lineBreak + strings.Join(node.Methods, ", ")
+}
+
+// label returns the relation of the edge, with its method and condition if any.
+func (edge GraphEdge) label() string {
+	label := edge.Rel
+	if edge.Method != "" {
+		label += " (" + edge.Method + ")"
+	}
+	if edge.Condition != "" {
+		label += " [" + edge.Condition + "]"
+	}
+	return label
+}
+
+// mermaidText escapes the characters that end a quoted Mermaid label.
+func mermaidText(s string) string {
+	return strings.NewReplacer(`"`, "#quot;").Replace(s)
+}
+
+// serveGraph serves the resource graph as JSON, or as DOT or Mermaid with ?format=dot or ?format=mermaid.
+func (a *Admin) serveGraph(res http.ResponseWriter, req *http.Request) {
+	g := a.itsy.Graph()
+	switch req.URL.Query().Get("format") {
+	case "dot":
+		res.Header().Set(HeaderContentType, MIMETextGraphviz)
+		res.Write([]byte(g.DOT()))
+	case "mermaid":
+		res.Header().Set(HeaderContentType, MIMETextPlain+"; charset=utf-8")
+		res.Write([]byte(g.Mermaid()))
+	default:
+		writeJSON(res, g)
+	}
+}
@@ -0,0 +1,108 @@
+package itsy
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+func newGraphTestItsy() *Itsy {
+	i := New()
+	handler := func(c Context) error { return nil }
+	root := i.Register("/")
+	root.GET(handler)
+	orders := i.Register("/orders/:id")
+	orders.GET(handler)
+	orders.DELETE(handler)
+	root.Link("/orders/{id}", "order", LinkTemplated())
+	orders.Link("/", "home")
+	orders.Link("/orders/:id", "cancel", LinkMethod(DELETE), LinkCondition("cancellable", func(c Context) bool { return true }))
+	orders.Link("/orders/:id", "refresh", LinkWhen(func(c Context) bool { return true }))
+	orders.Link("https://tracking.example.com/{id}", "tracking")
+	return i
+}
+
+func TestGraph(t *testing.T) {
+	g := newGraphTestItsy().Graph()
+
+	if len(g.Nodes) != 2 || g.Nodes[0].Path != "/" || g.Nodes[1].Path != "/orders/:id" || len(g.Nodes[1].Methods) != 2 {
+		t.Fatalf("Unexpected nodes: %+v", g.Nodes)
+	}
+	expected := []GraphEdge{
+		{From: "/", To: "/orders/:id", Href: "/orders/{id}", Rel: "order"},
+		{From: "/orders/:id", To: "/", Href: "/", Rel: "home"},
+		{From: "/orders/:id", To: "/orders/:id", Href: "/orders/:id", Rel: "cancel", Method: DELETE, Condition: "cancellable"},
+		{From: "/orders/:id", To: "/orders/:id", Href: "/orders/:id", Rel: "refresh", Condition: UnnamedCondition},
+		{From: "/orders/:id", To: "https://tracking.example.com/{id}", Href: "https://tracking.example.com/{id}", Rel: "tracking"},
+	}
+	if len(g.Edges) != len(expected) {
+		t.Fatalf("Expected %d edges, got %+v", len(expected), g.Edges)
+	}
+	for n, edge := range expected {
+		if g.Edges[n] != edge {
+			t.Errorf("Expected edge %+v, got %+v", edge, g.Edges[n])
+		}
+	}
+
+	dot := `digraph itsy {
+	node [shape=box];
+	"/" [label="/\nGET"];
+	"/orders/:id" [label="/orders/:id\nGET, DELETE"];
+	"https://tracking.example.com/{id}" [shape=plaintext];
+	"/" -> "/orders/:id" [label="order"];
+	"/orders/:id" -> "/" [label="home"];
+	"/orders/:id" -> "/orders/:id" [label="cancel (DELETE) [cancellable]", style=dashed];
+	"/orders/:id" -> "/orders/:id" [label="refresh [conditional]", style=dashed];
+	"/orders/:id" -> "https://tracking.example.com/{id}" [label="tracking"];
+}
+`
+	if got := g.DOT(); got != dot {
+		t.Errorf("Expected DOT\n%s\ngot\n%s", dot, got)
+	}
+
+	mermaid := `flowchart LR
+	n0["/<br/>GET"]
+	n1["/orders/:id<br/>GET, DELETE"]
+	x0("https://tracking.example.com/{id}")
+	n0 -->|"order"| n1
+	n1 -->|"home"| n0
+	n1 -.->|"cancel (DELETE) [cancellable]"| n1
+	n1 -.->|"refresh [conditional]"| n1
+	n1 -->|"tracking"| x0
+`
+	if got := g.Mermaid(); got != mermaid {
+		t.Errorf("Expected Mermaid\n%s\ngot\n%s", mermaid, got)
+	}
+}
+
+func TestAdminGraph(t *testing.T) {
+	i := newGraphTestItsy()
+	i.Admin(AdminConfig{Auth: AdminBasicAuth("admin", "secret")})
+
+	get := func(target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(GET, target, nil)
+		req.SetBasicAuth("admin", "secret")
+		rr := httptest.NewRecorder()
+		i.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := get("/debug/graph")
+	var g Graph
+	if err := json.Unmarshal(rr.Body.Bytes(), &g); err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Nodes) != 2 || len(g.Edges) != 5 || g.Edges[2].Condition != "cancellable" {
+		t.Errorf("Unexpected graph: %+v", g)
+	}
+
+	rr = get("/debug/graph?format=dot")
+	if rr.Header().Get(HeaderContentType) != MIMETextGraphviz || rr.Body.String() != i.Graph().DOT() {
+		t.Errorf("Expected the DOT graph, got %q", rr.Body.String())
+	}
+
+	rr = get("/debug/graph?format=mermaid")
+	if rr.Body.String() != i.Graph().Mermaid() {
+		t.Errorf("Expected the Mermaid graph, got %q", rr.Body.String())
+	}
+}
@@ -0,0 +1,309 @@
+package itsy
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// MIMEAppHALJSON is the HAL JSON media type.
+const MIMEAppHALJSON = "application/hal+json"
+
+type (
+	// HALLink is a link object of a HAL document.
+	HALLink struct {
+		Href        string `json:"href"`
+		Templated   bool   `json:"templated,omitempty"`
+		Type        string `json:"type,omitempty"`
+		Deprecation string `json:"deprecation,omitempty"`
+		Name        string `json:"name,omitempty"`
+		Profile     string `json:"profile,omitempty"`
+		Title       string `json:"title,omitempty"`
+		Hreflang    string `json:"hreflang,omitempty"`
+		Method      string `json:"method,omitempty"`
+	}
+	// HALTemplate is a HAL-FORMS template.
+	HALTemplate struct {
+		Title       string        `json:"title,omitempty"`
+		Method      string        `json:"method"`
+		ContentType string        `json:"contentType,omitempty"`
+		Target      string        `json:"target,omitempty"`
+		Properties  []HALProperty `json:"properties"`
+	}
+	// HALProperty is a property of a HAL-FORMS template.
+	HALProperty struct {
+		Name      string      `json:"name"`
+		Prompt    string      `json:"prompt,omitempty"`
+		Type      string      `json:"type,omitempty"`
+		Value     interface{} `json:"value,omitempty"`
+		Required  bool        `json:"required,omitempty"`
+		Regex     string      `json:"regex,omitempty"`
+		Min       *float64    `json:"min,omitempty"`
+		Max       *float64    `json:"max,omitempty"`
+		MinLength int         `json:"minLength,omitempty"`
+		MaxLength int         `json:"maxLength,omitempty"`
+		Options   *HALOptions `json:"options,omitempty"`
+	}
+	// HALOptions are the values to choose from for a HAL-FORMS property.
+	HALOptions struct {
+		Inline []HALOption `json:"inline"`
+	}
+	// HALOption is a value to choose from for a HAL-FORMS property.
+	HALOption struct {
+		Prompt string `json:"prompt"`
+		Value  string `json:"value"`
+	}
+	// HALDocument is a decoded HAL resource object.
+	HALDocument struct {
+		Links      map[string][]HALLink      // Links by relation, including self.
+		Embedded   map[string][]*HALDocument // Embedded resources by relation.
+		Templates  map[string]HALTemplate    // HAL-FORMS templates by key.
+		Properties map[string]interface{}    // The resource's state.
+	}
+	// halEncoder renders a representation as HAL JSON.
+	halEncoder struct{}
+)
+
+// Encode writes the representation as a HAL document.
+func (halEncoder) Encode(w io.Writer, rep *Representation) error {
+	doc, err := newHALObject(rep)
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(doc)
+}
+
+// EncodeError writes the error as a HAL document.
+func (halEncoder) EncodeError(w io.Writer, err *HTTPError) error {
+	return json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": err.Status,
+		"title":  err.Title(),
+		"detail": err.Message,
+	})
+}
+
+// newHALObject creates the HAL resource object of a representation.
+func newHALObject(rep *Representation) (map[string]interface{}, error) {
+	doc, err := objectProperties(rep.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	links := map[string][]HALLink{"self": {{Href: rep.Self}}}
+	rels := []string{"self"}
+	for _, link := range rep.Hypermedia.Links {
+		if _, ok := links[link.Rel]; !ok {
+			rels = append(rels, link.Rel)
+		}
+		links[link.Rel] = append(links[link.Rel], HALLink{
+			Href:        link.Href,
+			Templated:   link.Templated,
+			Type:        link.Type,
+			Deprecation: link.Deprecation,
+			Name:        link.Name,
+			Profile:     link.Profile,
+			Title:       link.Title,
+			Hreflang:    link.Hreflang,
+			Method:      link.Method,
+		})
+	}
+	halLinks := make(map[string]interface{}, len(links))
+	for _, rel := range rels {
+		halLinks[rel] = oneOrMany(links[rel])
+	}
+	doc["_links"] = halLinks
+
+	if forms := rep.Hypermedia.allForms(); len(forms) > 0 {
+		doc["_templates"] = halTemplates(forms)
+	}
+
+	if len(rep.Embedded) > 0 {
+		embedded := make(map[string]interface{}, len(rep.Embedded))
+		for rel, reps := range rep.Embedded {
+			objects := make([]map[string]interface{}, 0, len(reps))
+			for _, e := range reps {
+				object, err := newHALObject(e)
+				if err != nil {
+					return nil, err
+				}
+				objects = append(objects, object)
+			}
+			embedded[rel] = oneOrMany(objects)
+		}
+		doc["_embedded"] = embedded
+	}
+
+	return doc, nil
+}
+
+// halTemplates converts forms to HAL-FORMS templates. The first form is the "default" template
+// and the others are keyed by name.
+func halTemplates(forms []Form) map[string]HALTemplate {
+	templates := make(map[string]HALTemplate, len(forms))
+	for n, form := range forms {
+		template := HALTemplate{
+			Title:       form.Title,
+			Method:      form.Method,
+			ContentType: form.Enctype,
+			Target:      form.Action,
+			Properties:  make([]HALProperty, 0, len(form.Fields)),
+		}
+		if template.Title == "" {
+			template.Title = form.Name
+		}
+		for _, field := range form.Fields {
+			property := HALProperty{
+				Name:      field.Name,
+				Prompt:    field.Title,
+				Type:      field.Type,
+				Value:     field.Value,
+				Required:  field.Required,
+				Regex:     field.Pattern,
+				Min:       field.Min,
+				Max:       field.Max,
+				MinLength: field.MinLength,
+				MaxLength: field.MaxLength,
+			}
+			if len(field.Options) > 0 {
+				property.Options = &HALOptions{Inline: make([]HALOption, 0, len(field.Options))}
+				for _, option := range field.Options {
+					prompt := option.Title
+					if prompt == "" {
+						prompt = option.Value
+					}
+					property.Options.Inline = append(property.Options.Inline, HALOption{Prompt: prompt, Value: option.Value})
+				}
+			}
+			template.Properties = append(template.Properties, property)
+		}
+
+		key := form.Name
+		if n == 0 {
+			key = "default"
+		}
+		templates[key] = template
+	}
+	return templates
+}
+
+// objectProperties converts the data to the properties of a JSON resource object.
+// Data that isn't a JSON object is kept under the "value" property.
+func objectProperties(data interface{}) (map[string]interface{}, error) {
+	properties := make(map[string]interface{})
+	if data == nil {
+		return properties, nil
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(encoded, &properties); err != nil {
+		properties = map[string]interface{}{"value": data}
+	}
+	return properties, nil
+}
+
+// oneOrMany returns the only element of a slice, or the slice if it has several.
+func oneOrMany[T any](items []T) interface{} {
+	if len(items) == 1 {
+		return items[0]
+	}
+	return items
+}
+
+// DecodeHAL decodes a HAL document, e.g. a response body in a test or client.
+func DecodeHAL(r io.Reader) (*HALDocument, error) {
+	doc := &HALDocument{}
+	if err := json.NewDecoder(r).Decode(doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// UnmarshalJSON decodes a HAL resource object, accepting single objects or arrays for links and embedded resources.
+func (d *HALDocument) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if raw == nil {
+		return errors.New("HAL document is not an object")
+	}
+
+	d.Links = make(map[string][]HALLink)
+	d.Embedded = make(map[string][]*HALDocument)
+	d.Templates = make(map[string]HALTemplate)
+	d.Properties = make(map[string]interface{})
+
+	for key, value := range raw {
+		switch key {
+		case "_links":
+			var rels map[string]json.RawMessage
+			if err := json.Unmarshal(value, &rels); err != nil {
+				return err
+			}
+			for rel, links := range rels {
+				if err := unmarshalOneOrMany(links, func(item json.RawMessage) error {
+					var link HALLink
+					if err := json.Unmarshal(item, &link); err != nil {
+						return err
+					}
+					d.Links[rel] = append(d.Links[rel], link)
+					return nil
+				}); err != nil {
+					return err
+				}
+			}
+		case "_embedded":
+			var rels map[string]json.RawMessage
+			if err := json.Unmarshal(value, &rels); err != nil {
+				return err
+			}
+			for rel, docs := range rels {
+				if err := unmarshalOneOrMany(docs, func(item json.RawMessage) error {
+					embedded := &HALDocument{}
+					if err := json.Unmarshal(item, embedded); err != nil {
+						return err
+					}
+					d.Embedded[rel] = append(d.Embedded[rel], embedded)
+					return nil
+				}); err != nil {
+					return err
+				}
+			}
+		case "_templates":
+			if err := json.Unmarshal(value, &d.Templates); err != nil {
+				return err
+			}
+		default:
+			var property interface{}
+			if err := json.Unmarshal(value, &property); err != nil {
+				return err
+			}
+			d.Properties[key] = property
+		}
+	}
+	return nil
+}
+
+// Link returns the first link with the relation.
+func (d *HALDocument) Link(rel string) (HALLink, bool) {
+	if links := d.Links[rel]; len(links) > 0 {
+		return links[0], true
+	}
+	return HALLink{}, false
+}
+
+// unmarshalOneOrMany calls fn for a single JSON value or for each element of a JSON array.
+func unmarshalOneOrMany(data json.RawMessage, fn func(json.RawMessage) error) error {
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return fn(data)
+	}
+	for _, item := range items {
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package itsy
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHAL(t *testing.T) {
+	type order struct {
+		ID    string  `json:"id"`
+		Total float64 `json:"total"`
+	}
+
+	i := New()
+	i.Register("/orders/:id").GET(func(c Context) error {
+		return c.Render(StatusOK, order{ID: c.GetParamValue("id"), Total: 9.5})
+	})
+	i.Register("/customers/:customer").GET(func(c Context) error {
+		return c.Render(StatusOK, nil)
+	})
+	i.Register("/items/:id").GET(func(c Context) error {
+		return c.Render(StatusOK, nil)
+	})
+	orders := i.Resource("/orders/:id")
+	orders.Link("/customers/:customer", "customer")
+	orders.Link("/items/:id", "item")
+	orders.Link("/orders/:id", "item")
+
+	req := httptest.NewRequest(GET, "/orders/42", nil)
+	req.Header.Set(HeaderAccept, MIMEAppHALJSON)
+	rr := httptest.NewRecorder()
+	i.ServeHTTP(rr, req)
+
+	if rr.Header().Get(HeaderContentType) != MIMEAppHALJSON {
+		t.Fatalf("Unexpected content type: %q", rr.Header().Get(HeaderContentType))
+	}
+	doc, err := DecodeHAL(rr.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// State is kept in properties.
+	if doc.Properties["id"] != "42" || doc.Properties["total"] != 9.5 {
+		t.Errorf("Unexpected properties: %v", doc.Properties)
+	}
+
+	// Self is added, unresolved parameters are templated and repeated relations are arrays.
+	if self, _ := doc.Link("self"); self.Href != "/orders/42" {
+		t.Errorf("Unexpected self link: %+v", self)
+	}
+	if customer, _ := doc.Link("customer"); customer.Href != "/customers/{customer}" || !customer.Templated {
+		t.Errorf("Unexpected customer link: %+v", customer)
+	}
+	if items := doc.Links["item"]; len(items) != 2 || items[0].Href != "/items/42" || items[1].Href != "/orders/42" {
+		t.Errorf("Unexpected item links: %+v", items)
+	}
+}
+
+func TestDecodeHALEmbedded(t *testing.T) {
+	body := `{
+		"_links": {"self": {"href": "/orders"}},
+		"_embedded": {
+			"order": [
+				{"_links": {"self": {"href": "/orders/1"}}, "total": 1},
+				{"_links": {"self": {"href": "/orders/2"}}, "total": 2}
+			],
+			"customer": {"_links": {"self": {"href": "/customers/7"}}}
+		},
+		"count": 2
+	}`
+
+	doc, err := DecodeHAL(strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Embedded["order"]) != 2 || doc.Embedded["order"][1].Properties["total"] != 2.0 {
+		t.Errorf("Unexpected embedded orders: %+v", doc.Embedded["order"])
+	}
+	if customer, _ := doc.Embedded["customer"][0].Link("self"); customer.Href != "/customers/7" {
+		t.Errorf("Unexpected embedded customer: %+v", doc.Embedded["customer"])
+	}
+	if doc.Properties["count"] != 2.0 {
+		t.Errorf("Unexpected properties: %v", doc.Properties)
+	}
+}
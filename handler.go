@@ -2,6 +2,9 @@ package itsy
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"go.uber.org/zap"
 )
@@ -9,21 +12,58 @@ import (
 // ServeHTTP is the main entry point for the Itsy instance.
 func (i *Itsy) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 	path := req.URL.Path
+	if h := i.mounted(path); h != nil {
+		h.ServeHTTP(res, req)
+		return
+	}
+
 	c := i.prepareRequestContext(res, req, path)
+	if root := c.span; root != nil {
+		defer func() {
+			if resource := c.Resource(); resource != nil {
+				root.Name = "HTTP " + req.Method + " " + resource.Path()
+				root.SetAttribute("http.route", resource.Path())
+			}
+			if c.Response().Committed() {
+				root.SetAttribute("http.status_code", strconv.Itoa(c.Response().StatusCode))
+			}
+			root.Finish()
+		}()
+	}
+	if i.metrics != nil {
+		i.metrics.track()
+		defer i.metrics.observe(c, time.Now())
+	}
 
+	span := c.StartSpan("routing")
 	n := i.processRouteSegments(c, path)
+	span.Finish()
 	if n == nil {
 		i.Logger.Error("No route found", zap.String("path", path))
 		return
 	}
-	i.handleRequestNode(n, c, req, res)
+	c.SetResource(n.resource)
+	i.handleRequestNode(n, c, req, c.Response())
+}
+
+// mounted returns the handler mounted at a prefix of the path, if any.
+func (i *Itsy) mounted(path string) http.Handler {
+	for _, m := range i.mounts {
+		if path == m.prefix || strings.HasPrefix(path, m.prefix+"/") {
+			return m.handler
+		}
+	}
+	return nil
 }
 
-// prepareRequestContext creates a new context for the request.
-func (i *Itsy) prepareRequestContext(res http.ResponseWriter, req *http.Request, path string) Context {
+// prepareRequestContext creates a new context for the request, starting its trace if tracing is enabled.
+func (i *Itsy) prepareRequestContext(res http.ResponseWriter, req *http.Request, path string) *baseContext {
 	c := newBaseContext(req, NewResponse(res, i), i.Resource(path), path, i)
-	if c.Request().Header.Get(HeaderAccept) == "" {
-		c.Request().Header.Set(HeaderContentType, MIMETextHTML)
+	if i.tracer != nil {
+		parent, _ := extractSpanContext(req)
+		c.span = i.tracer.start("HTTP "+req.Method, parent)
+		c.span.SetAttribute("http.method", req.Method)
+		c.span.SetAttribute("http.target", req.URL.RequestURI())
 	}
 	return c
 }
@@ -48,7 +88,7 @@ func (i *Itsy) processRouteSegments(c Context, path string) *node {
 				}
 			}
 			if !found {
-				i.sendHTTPError(StatusNotFound, "Resource does not exist", c.Response().Writer, i.Logger)
+				i.renderError(c, StatusNotFound, "Resource does not exist")
 				return nil
 			}
 		}
@@ -59,37 +99,87 @@ func (i *Itsy) processRouteSegments(c Context, path string) *node {
 // handleRequestNode handles the request node by calling the appropriate handler.
 func (i *Itsy) handleRequestNode(n *node, c Context, req *http.Request, res http.ResponseWriter) {
 	if n == nil {
-		i.sendHTTPError(StatusNotFound, "Resource does not exist", res, i.Logger)
+		i.renderError(c, StatusNotFound, "Resource does not exist")
 		return
 	}
 
-	if n.resource != nil {
-		switch req.Method {
-		case GET:
-			if n.resource.Handler(GET) == nil {
-				i.sendHTTPError(StatusMethodNotAllowed, "Handler does not exist for the request method", res, i.Logger)
-				return
-			}
-			callHandler(n.resource, GET, c)
-		case POST:
-			if n.resource.Handler(POST) == nil {
-				i.sendHTTPError(StatusMethodNotAllowed, "Handler does not exist for the request method", res, i.Logger)
-				return
-			}
-			callHandler(n.resource, POST, c)
-		default:
-			i.sendHTTPError(StatusMethodNotAllowed, "Handler does not exist for the request method", res, i.Logger)
-		}
-	} else {
-		i.sendHTTPError(StatusNotFound, "Resource does not exist", res, i.Logger)
+	if n.resource == nil {
+		i.renderError(c, StatusNotFound, "Resource does not exist")
+		return
 	}
-}
 
-// callHandler calls the handler of the resource.
-func callHandler(resource Resource, method string, c Context) error {
-	handler := resource.Handler(method)
+	handler := n.resource.Handler(req.Method)
+	if handler == nil && req.Method == OPTIONS {
+		handler = optionsHandler
+	}
+	if handler == nil && req.Method == HEAD {
+		handler = headHandler(n.resource.Handler(GET))
+	}
 	if handler == nil {
+		res.Header().Set(HeaderAllow, strings.Join(allowedMethods(n.resource), ", "))
+		i.renderError(c, StatusMethodNotAllowed, "Handler does not exist for the request method")
+		return
+	}
+	if i.LinkHeaders {
+		i.addLinkHeaders(c)
+	}
+	if err := i.callHandler(i.guardTransitions(handler), c); err != nil {
+		i.handleError(c, err)
+	}
+}
+
+// headHandler answers HEAD requests with the headers of the GET handler and no body.
+func headHandler(get HandlerFunc) HandlerFunc {
+	if get == nil {
 		return nil
 	}
-	return handler(c)
+	return func(c Context) error {
+		c.Response().discard = true
+		return get(c)
+	}
+}
+
+// optionsHandler answers OPTIONS requests for resources without an OPTIONS handler.
+func optionsHandler(c Context) error {
+	w := c.Response()
+	w.Header().Set(HeaderAllow, strings.Join(allowedMethods(c.Resource()), ", "))
+	w.WriteHeader(StatusNoContent)
+	return nil
+}
+
+// allowedMethods returns the methods a resource answers, including HEAD with GET and OPTIONS.
+func allowedMethods(resource Resource) []string {
+	methods := resourceMethods(resource)
+	if len(methods) > 0 && methods[0] == GET && resource.Handler(HEAD) == nil {
+		methods = append([]string{GET, HEAD}, methods[1:]...)
+	}
+	if resource.Handler(OPTIONS) == nil {
+		methods = append(methods, OPTIONS)
+	}
+	return methods
+}
+
+// callHandler calls the handler through the middleware chain.
+func (i *Itsy) callHandler(handler HandlerFunc, c Context) error {
+	tracing := i.tracer != nil
+	if tracing {
+		handler = traced("handler", handler)
+	}
+	for n := len(i.middleware) - 1; n >= 0; n-- {
+		handler = i.middleware[n](c, handler)
+		if tracing {
+			handler = traced("middleware "+middlewareName(i.middleware[n]), handler)
+		}
+	}
+
+	if err := handler(c); err != nil {
+		log := c.Logger().Error
+		if embedded(c) {
+			// Embedded resources that fail are left as links.
+			log = c.Logger().Debug
+		}
+		log("Handler failed", zap.String("path", c.Path()), zap.Error(err))
+		return err
+	}
+	return nil
 }
@@ -0,0 +1,228 @@
+package itsy
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultHealthCheckTimeout is the timeout applied to a health check that doesn't set one.
+	DefaultHealthCheckTimeout = time.Second
+
+	// Health resource paths.
+	HealthPath    = "/healthz"
+	ReadinessPath = "/readyz"
+	LivenessPath  = "/livez"
+
+	// Health statuses.
+	HealthStatusUp   = "up"
+	HealthStatusDown = "down"
+)
+
+type (
+	// HealthChecker checks the health of a single dependency.
+	HealthChecker interface {
+		CheckHealth(ctx context.Context) error // Return an error if the dependency is unhealthy.
+	}
+	// HealthCheckerFunc adapts an ordinary function to a HealthChecker.
+	HealthCheckerFunc func(ctx context.Context) error
+	// HealthCheck describes a named health check.
+	HealthCheck struct {
+		Name     string        // The name reported in the aggregated result.
+		Checker  HealthChecker // The checker to run.
+		Timeout  time.Duration // The time the check may take, DefaultHealthCheckTimeout if zero.
+		CacheTTL time.Duration // How long a result is reused, no caching if zero.
+	}
+	// HealthReport is the aggregated result of a set of health checks.
+	HealthReport struct {
+		Status string                       `json:"status"`
+		Checks map[string]HealthCheckResult `json:"checks"`
+	}
+	// HealthCheckResult is the result of a single health check.
+	HealthCheckResult struct {
+		Status    string    `json:"status"`
+		Error     string    `json:"error,omitempty"`
+		Duration  string    `json:"duration"`
+		CheckedAt time.Time `json:"checkedAt"`
+	}
+	// Health manages the health, readiness and liveness resources.
+	Health struct {
+		itsy      *Itsy
+		mu        sync.RWMutex
+		readiness []*healthEntry
+		liveness  []*healthEntry
+	}
+	// healthEntry is a registered health check and its cached result.
+	healthEntry struct {
+		check  HealthCheck
+		mu     sync.Mutex
+		result HealthCheckResult
+	}
+)
+
+// CheckHealth calls f(ctx).
+func (f HealthCheckerFunc) CheckHealth(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Health registers the operational health, readiness and liveness resources and links them from the root resource.
+// If no root resource is registered, Health registers one at "/" whose GET handler writes its links
+// as HTML. An error is returned if the links can't be added to the root resource.
+func (i *Itsy) Health() (*Health, error) {
+	if i.health != nil {
+		return i.health, nil
+	}
+	h := &Health{itsy: i}
+	i.health = h
+
+	i.Register(HealthPath).GET(func(c Context) error {
+		h.mu.RLock()
+		entries := append(append([]*healthEntry{}, h.readiness...), h.liveness...)
+		h.mu.RUnlock()
+		return h.write(c, h.run(c.Request().Context(), entries), true)
+	})
+	i.Register(ReadinessPath).GET(func(c Context) error {
+		h.mu.RLock()
+		entries := append([]*healthEntry{}, h.readiness...)
+		h.mu.RUnlock()
+		return h.write(c, h.run(c.Request().Context(), entries), true)
+	})
+	i.Register(LivenessPath).GET(func(c Context) error {
+		h.mu.RLock()
+		entries := append([]*healthEntry{}, h.liveness...)
+		h.mu.RUnlock()
+		return h.write(c, h.run(c.Request().Context(), entries), false)
+	})
+
+	root := i.Resource("/")
+	if root == nil {
+		root = i.Register("/")
+		root.GET(func(c Context) error {
+			return c.WriteHTML()
+		})
+	}
+	for _, link := range []struct{ href, rel string }{
+		{HealthPath, "health"},
+		{ReadinessPath, "readiness"},
+		{LivenessPath, "liveness"},
+	} {
+		i.Resource(link.href).(OperationalMarker).Operational()
+		if err := root.Link(link.href, link.rel); err != nil {
+			return nil, err
+		}
+	}
+
+	return h, nil
+}
+
+// AddReadinessCheck adds a check that must pass for the instance to receive traffic.
+func (h *Health) AddReadinessCheck(check HealthCheck) *Health {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.readiness = append(h.readiness, &healthEntry{check: check})
+	return h
+}
+
+// AddLivenessCheck adds a check that must pass for the instance to be considered alive.
+func (h *Health) AddLivenessCheck(check HealthCheck) *Health {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.liveness = append(h.liveness, &healthEntry{check: check})
+	return h
+}
+
+// run runs the checks concurrently and aggregates their results.
+func (h *Health) run(ctx context.Context, entries []*healthEntry) HealthReport {
+	report := HealthReport{
+		Status: HealthStatusUp,
+		Checks: make(map[string]HealthCheckResult, len(entries)),
+	}
+
+	results := make([]HealthCheckResult, len(entries))
+	var wg sync.WaitGroup
+	for n, e := range entries {
+		wg.Add(1)
+		go func(n int, e *healthEntry) {
+			defer wg.Done()
+			results[n] = e.run(ctx)
+		}(n, e)
+	}
+	wg.Wait()
+
+	for n, e := range entries {
+		report.Checks[e.check.Name] = results[n]
+		if results[n].Status != HealthStatusUp {
+			report.Status = HealthStatusDown
+		}
+	}
+	return report
+}
+
+// write writes the report as JSON, reporting not-ready while the server is draining.
+func (h *Health) write(c Context, report HealthReport, drain bool) error {
+	if drain && h.itsy.Draining() {
+		report.Status = HealthStatusDown
+		report.Checks["shutdown"] = HealthCheckResult{
+			Status:    HealthStatusDown,
+			Error:     "server is shutting down",
+			Duration:  time.Duration(0).String(),
+			CheckedAt: time.Now(),
+		}
+	}
+
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	status := StatusOK
+	if report.Status != HealthStatusUp {
+		status = StatusServiceUnavailable
+	}
+	w := c.Response()
+	w.Header().Set(HeaderContentType, MIMEAppJSON)
+	w.WriteHeader(status)
+	_, err = w.Write(body)
+	return err
+}
+
+// run runs the check, reusing the cached result while it is fresh.
+func (e *healthEntry) run(ctx context.Context) HealthCheckResult {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.check.CacheTTL > 0 && !e.result.CheckedAt.IsZero() && time.Since(e.result.CheckedAt) < e.check.CacheTTL {
+		return e.result
+	}
+
+	timeout := e.check.Timeout
+	if timeout <= 0 {
+		timeout = DefaultHealthCheckTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	errc := make(chan error, 1)
+	go func() { errc <- e.check.Checker.CheckHealth(ctx) }()
+
+	var err error
+	select {
+	case err = <-errc:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	e.result = HealthCheckResult{
+		Status:    HealthStatusUp,
+		Duration:  time.Since(start).String(),
+		CheckedAt: start,
+	}
+	if err != nil {
+		e.result.Status = HealthStatusDown
+		e.result.Error = err.Error()
+	}
+	return e.result
+}
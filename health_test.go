@@ -0,0 +1,108 @@
+package itsy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHealth(t *testing.T) {
+	i := New()
+
+	// Register a cached readiness check that counts its calls, and a failing liveness check.
+	var calls atomic.Int32
+	health, err := i.Health()
+	if err != nil {
+		t.Fatal(err)
+	}
+	health.
+		AddReadinessCheck(HealthCheck{
+			Name: "db",
+			Checker: HealthCheckerFunc(func(ctx context.Context) error {
+				calls.Add(1)
+				return nil
+			}),
+			CacheTTL: time.Minute,
+		}).
+		AddLivenessCheck(HealthCheck{
+			Name: "deadlock",
+			Checker: HealthCheckerFunc(func(ctx context.Context) error {
+				return errors.New("stuck")
+			}),
+		})
+
+	// The readiness resource is up and the check is cached.
+	for n := 0; n < 2; n++ {
+		rr := httptest.NewRecorder()
+		i.ServeHTTP(rr, httptest.NewRequest(GET, ReadinessPath, nil))
+		if rr.Code != StatusOK {
+			t.Fatalf("Expected status %d, got %d", StatusOK, rr.Code)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Errorf("Expected the check to run once, ran %d times", calls.Load())
+	}
+
+	// The aggregated health resource reports the failing check.
+	rr := httptest.NewRecorder()
+	i.ServeHTTP(rr, httptest.NewRequest(GET, HealthPath, nil))
+	if rr.Code != StatusServiceUnavailable {
+		t.Fatalf("Expected status %d, got %d", StatusServiceUnavailable, rr.Code)
+	}
+	var report HealthReport
+	if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Status != HealthStatusDown || report.Checks["db"].Status != HealthStatusUp || report.Checks["deadlock"].Error != "stuck" {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+
+	// The root resource links to the health resources.
+	rr = httptest.NewRecorder()
+	i.ServeHTTP(rr, httptest.NewRequest(GET, "/", nil))
+	if !strings.Contains(rr.Body.String(), `<a href="/readyz" rel="readiness"></a>`) {
+		t.Errorf("Expected link to %s, got %s", ReadinessPath, rr.Body.String())
+	}
+}
+
+func TestHealthTimeoutAndDraining(t *testing.T) {
+	i := New()
+
+	// Register a readiness check that never finishes in time.
+	health, err := i.Health()
+	if err != nil {
+		t.Fatal(err)
+	}
+	health.AddReadinessCheck(HealthCheck{
+		Name: "slow",
+		Checker: HealthCheckerFunc(func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}),
+		Timeout: 10 * time.Millisecond,
+	})
+
+	rr := httptest.NewRecorder()
+	i.ServeHTTP(rr, httptest.NewRequest(GET, ReadinessPath, nil))
+	if !strings.Contains(rr.Body.String(), context.DeadlineExceeded.Error()) {
+		t.Errorf("Expected the check to time out, got %s", rr.Body.String())
+	}
+
+	// The instance is not ready while draining, but is still alive.
+	i.draining.Store(true)
+	rr = httptest.NewRecorder()
+	i.ServeHTTP(rr, httptest.NewRequest(GET, ReadinessPath, nil))
+	if rr.Code != StatusServiceUnavailable || !strings.Contains(rr.Body.String(), "shutting down") {
+		t.Errorf("Expected not-ready while draining, got %d %s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	i.ServeHTTP(rr, httptest.NewRequest(GET, LivenessPath, nil))
+	if rr.Code != StatusOK {
+		t.Errorf("Expected status %d, got %d", StatusOK, rr.Code)
+	}
+}
@@ -1,33 +1,320 @@
 package itsy
 
-import "regexp"
+import (
+	"regexp"
+	"strings"
+)
 
 type (
 	// Hypermedia represents a set of hypermedia controls.
 	Hypermedia struct {
-		Links []Link // The links.
+		Links   []Link   // The links.
+		Actions []Action // The actions.
+		Forms   []Form   // The forms.
 	}
 	// Link is a link to another resource.
 	Link struct {
-		re   *regexp.Regexp
-		Href string // The URL of the resource.
-		Rel  string // The relationship of the resource to the current resource.
+		template    *URITemplate
+		condition   *linkCondition
+		mapping     *linkMapping
+		forClients  bool
+		Href        string `json:"href"`                  // The URL of the resource.
+		Rel         string `json:"rel"`                   // The relationship of the resource to the current resource.
+		Templated   bool   `json:"templated,omitempty"`   // Whether the href is a URI template with unresolved parameters.
+		Title       string `json:"title,omitempty"`       // A human-readable label of the link.
+		Type        string `json:"type,omitempty"`        // The media type of the linked resource.
+		Hreflang    string `json:"hreflang,omitempty"`    // The language of the linked resource.
+		Name        string `json:"name,omitempty"`        // A name that tells links with the same relation apart.
+		Profile     string `json:"profile,omitempty"`     // The URI of a profile of the linked resource.
+		Deprecation string `json:"deprecation,omitempty"` // The URI of information about the link's deprecation.
+		Method      string `json:"method,omitempty"`      // The method to follow the link with, if not GET.
+	}
+	// LinkOption sets a target attribute of a link.
+	LinkOption func(*Link)
+	// linkCondition decides per request whether a link is rendered.
+	linkCondition struct {
+		name string // The name of the condition, shown in the graph.
+		when func(Context) bool
+	}
+	// linkMapping says where the variables of a link's template get their values.
+	linkMapping struct {
+		params []paramMapping
+	}
+	// paramMapping is the source of one template variable.
+	paramMapping struct {
+		target string // The template variable.
+		source string // The request parameter, constant or handler value key.
+		kind   paramSource
+	}
+	// paramSource is where a template variable gets its value from.
+	paramSource int
+	// Linker is implemented by rendered values that contribute links of their own, such as a page of a collection.
+	Linker interface {
+		Links(c Context) []Link // The links of the value for the request.
+	}
+	// Action is an operation on a resource, backed by one of its non-GET handlers.
+	Action struct {
+		Name   string  `json:"name"`             // The name of the action, unique within the resource.
+		Title  string  `json:"title,omitempty"`  // A human-readable description of the action.
+		Method string  `json:"method"`           // The method of the handler.
+		Href   string  `json:"href"`             // The URL of the resource.
+		Type   string  `json:"type,omitempty"`   // The media type of the request body.
+		Fields []Field `json:"fields,omitempty"` // The fields of the request body.
+	}
+	// Field is an input of an action.
+	Field struct {
+		pattern   *regexp.Regexp
+		Name      string        `json:"name"`                // The name of the field.
+		Type      string        `json:"type,omitempty"`      // The input type of the field, such as "text", "number", "email", "url" or "date".
+		Value     interface{}   `json:"value,omitempty"`     // The default value of the field.
+		Title     string        `json:"title,omitempty"`     // A human-readable label of the field.
+		Required  bool          `json:"required,omitempty"`  // Whether a value must be submitted.
+		Pattern   string        `json:"pattern,omitempty"`   // A regular expression the whole value must match.
+		Min       *float64      `json:"min,omitempty"`       // The minimum of a number.
+		Max       *float64      `json:"max,omitempty"`       // The maximum of a number.
+		MinLength int           `json:"minLength,omitempty"` // The minimum number of characters.
+		MaxLength int           `json:"maxLength,omitempty"` // The maximum number of characters.
+		Options   []FieldOption `json:"options,omitempty"`   // The values to choose from, if limited.
+	}
+	// FieldOption is a value to choose from for a field.
+	FieldOption struct {
+		Value string `json:"value"`           // The submitted value.
+		Title string `json:"title,omitempty"` // A human-readable label of the value.
 	}
 )
 
+const (
+	paramFromRequest paramSource = iota // A parameter of the current request.
+	paramFromConst                      // A constant.
+	paramFromHandler                    // A value the handler sets with Context.SetLinkValue.
+)
+
 // newHypermedia creates a new hypermedia instance.
 func newHypermedia() *Hypermedia {
 	return &Hypermedia{
-		Links: make([]Link, 0),
+		Links:   make([]Link, 0),
+		Actions: make([]Action, 0),
+		Forms:   make([]Form, 0),
+	}
+}
+
+// pathParam matches the :param placeholders of route paths.
+var pathParam = regexp.MustCompile(`/:(\w+)`)
+
+// newLink creates a new link. The href is an RFC 6570 URI template, in which
+// route placeholders such as /:id stand for the variable {id}.
+func newLink(href, rel string, opts ...LinkOption) (Link, error) {
+	template, err := ParseURITemplate(pathParam.ReplaceAllString(href, "/{$1}"))
+	if err != nil {
+		return Link{}, err
+	}
+	link := Link{
+		template: template,
+		Href:     href,
+		Rel:      rel,
+	}
+	for _, opt := range opts {
+		opt(&link)
+	}
+	return link, nil
+}
+
+// LinkWhen renders a link only for requests the predicate accepts, e.g. a "cancel" link
+// only for orders that can still be cancelled.
+func LinkWhen(predicate func(Context) bool) LinkOption {
+	return func(l *Link) { l.condition = &linkCondition{when: predicate} }
+}
+
+// LinkCondition is LinkWhen with a name for the condition, such as "cancellable",
+// which the resource graph shows on the link.
+func LinkCondition(name string, predicate func(Context) bool) LinkOption {
+	return func(l *Link) { l.condition = &linkCondition{name: name, when: predicate} }
+}
+
+// LinkParam fills the target variable from a parameter of the current request,
+// e.g. {id} of /users/{id} from :userId of /users/:userId/orders/:orderId.
+func LinkParam(target, source string) LinkOption {
+	return mapLinkParam(paramMapping{target: target, source: source, kind: paramFromRequest})
+}
+
+// LinkConst fills the target variable with a constant.
+func LinkConst(target, value string) LinkOption {
+	return mapLinkParam(paramMapping{target: target, source: value, kind: paramFromConst})
+}
+
+// LinkValue fills the target variable with the value the handler sets for key with Context.SetLinkValue.
+func LinkValue(target, key string) LinkOption {
+	return mapLinkParam(paramMapping{target: target, source: key, kind: paramFromHandler})
+}
+
+// mapLinkParam adds a parameter mapping to a link.
+func mapLinkParam(m paramMapping) LinkOption {
+	return func(l *Link) {
+		mapping := &linkMapping{params: []paramMapping{m}}
+		if l.mapping != nil {
+			mapping.params = append(append([]paramMapping{}, l.mapping.params...), m)
+		}
+		l.mapping = mapping
+	}
+}
+
+// values returns the template values of the link for the request: the request's parameters by name,
+// overridden by the mapped variables. Mapped variables without a value are left out.
+func (m *linkMapping) values(c Context, params map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return params
+	}
+	values := make(map[string]interface{}, len(params))
+	for name, value := range params {
+		values[name] = value
+	}
+	for _, p := range m.params {
+		delete(values, p.target)
+		switch p.kind {
+		case paramFromRequest:
+			if value, ok := params[p.source]; ok {
+				values[p.target] = value
+			}
+		case paramFromConst:
+			values[p.target] = p.source
+		case paramFromHandler:
+			if value, ok := c.LinkValue(p.source); ok {
+				values[p.target] = value
+			}
+		}
+	}
+	return values
+}
+
+// LinkTemplated declares that the link's unfilled variables are for clients to fill,
+// such as the {id} of a search form, so Validate doesn't report them.
+func LinkTemplated() LinkOption {
+	return func(l *Link) { l.forClients = true }
+}
+
+// LinkTitle sets the human-readable label of a link.
+func LinkTitle(title string) LinkOption {
+	return func(l *Link) { l.Title = title }
+}
+
+// LinkType sets the media type of the linked resource.
+func LinkType(mediaType string) LinkOption {
+	return func(l *Link) { l.Type = mediaType }
+}
+
+// LinkHreflang sets the language of the linked resource.
+func LinkHreflang(lang string) LinkOption {
+	return func(l *Link) { l.Hreflang = lang }
+}
+
+// LinkName sets the name that tells links with the same relation apart.
+func LinkName(name string) LinkOption {
+	return func(l *Link) { l.Name = name }
+}
+
+// LinkProfile sets the URI of a profile of the linked resource.
+func LinkProfile(profile string) LinkOption {
+	return func(l *Link) { l.Profile = profile }
+}
+
+// LinkDeprecation marks a link deprecated, pointing to information about the deprecation.
+func LinkDeprecation(uri string) LinkOption {
+	return func(l *Link) { l.Deprecation = uri }
+}
+
+// LinkMethod sets the method to follow a link with.
+func LinkMethod(method string) LinkOption {
+	return func(l *Link) { l.Method = method }
+}
+
+// linkPath returns the route path a link href points to: the template up to its query or
+// fragment, with simple path expressions written as route placeholders.
+func linkPath(href string) string {
+	if end := strings.IndexAny(href, "?#"); end >= 0 {
+		href = href[:end]
+	}
+	if end := strings.Index(href, "{&"); end >= 0 {
+		href = href[:end]
+	}
+	return templatePathParam.ReplaceAllString(strings.TrimSuffix(href, "{"), ":$1")
+}
+
+// templatePathParam matches simple template expressions that are whole path segments.
+var templatePathParam = regexp.MustCompile(`\{(\w+)\}`)
+
+// responseLinks returns the resolved links of the request's resource, those added for the response
+// and those of the transitions allowed in the resource's current state.
+func responseLinks(c Context) []Link {
+	links := make([]Link, 0)
+	if resource := c.Resource(); resource != nil {
+		links = append(links, resource.Links()...)
+	}
+	links = append(links, c.Links()...)
+	for _, t := range allowedTransitions(c) {
+		links = append(links, t.link)
+	}
+	return resolveLinks(c, links)
+}
+
+// resolveLinks returns copies of the links whose conditions hold for the request, with their templates
+// expanded with the request's parameter values or mapped values. Variables without a value are kept and
+// the link is marked templated.
+func resolveLinks(c Context, links []Link) []Link {
+	values := paramValues(c)
+	resolved := make([]Link, 0, len(links))
+	for _, link := range links {
+		if link.condition != nil && !link.condition.when(c) {
+			continue
+		}
+		if link.template != nil {
+			link.Href, link.Templated = link.template.PartialExpand(link.mapping.values(c, values))
+		}
+		resolved = append(resolved, link)
+	}
+	return resolved
+}
+
+// paramValues returns the values of the request's parameters by name.
+func paramValues(c Context) map[string]interface{} {
+	values := make(map[string]interface{})
+	for _, param := range c.GetParams() {
+		values[param.Name] = param.Value
+	}
+	return values
+}
+
+// paramValue returns the value of a request parameter, if present.
+func paramValue(c Context, name string) (string, bool) {
+	for _, param := range c.GetParams() {
+		if param.Name == name {
+			return param.Value, true
+		}
 	}
+	return "", false
 }
 
-// newLink creates a new link.
-func newLink(href, rel string) Link {
-	re := regexp.MustCompile(`:(\w+)`) // Pre-compile the regular expression.
-	return Link{
-		re:   re,
-		Href: href,
-		Rel:  rel,
+// resolveActions returns an action for each non-GET handler of the resource, targeting href.
+// Actions declared for a method supply its name, title and fields.
+func resolveActions(r Resource, href string) []Action {
+	declared := r.Hypermedia().Actions
+	actions := make([]Action, 0, len(declared))
+	for _, method := range resourceMethods(r) {
+		if method == GET {
+			continue
+		}
+		action := Action{Name: strings.ToLower(method), Method: method}
+		for _, d := range declared {
+			if d.Method == method {
+				action = d
+				break
+			}
+		}
+		action.Href = href
+		action.Fields = append([]Field(nil), action.Fields...)
+		if len(action.Fields) > 0 && action.Type == "" {
+			action.Type = MIMEAppForm
+		}
+		actions = append(actions, action)
 	}
+	return actions
 }
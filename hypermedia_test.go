@@ -0,0 +1,195 @@
+package itsy
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLinkAttributes(t *testing.T) {
+	i := New()
+	i.LinkHeaders = true
+	i.Register("/orders/:id").GET(func(c Context) error {
+		return c.Render(StatusOK, map[string]string{"status": "open"})
+	})
+	i.Register("/orders/:id/invoice").GET(func(c Context) error {
+		return c.Render(StatusOK, nil)
+	})
+	err := i.Resource("/orders/:id").Link("/orders/:id/invoice", "invoice",
+		LinkTitle(`Invoice <PDF>`),
+		LinkType("application/pdf"),
+		LinkHreflang("en"),
+		LinkName("pdf"),
+		LinkProfile("https://example.com/profiles/invoice"),
+		LinkDeprecation("https://example.com/deprecations/invoice"),
+		LinkMethod(GET),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	render := func(accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(GET, "/orders/7", nil)
+		req.Header.Set(HeaderAccept, accept)
+		rr := httptest.NewRecorder()
+		i.ServeHTTP(rr, req)
+		return rr
+	}
+	expected := Link{
+		Href:        "/orders/7/invoice",
+		Rel:         "invoice",
+		Title:       "Invoice <PDF>",
+		Type:        "application/pdf",
+		Hreflang:    "en",
+		Name:        "pdf",
+		Profile:     "https://example.com/profiles/invoice",
+		Deprecation: "https://example.com/deprecations/invoice",
+		Method:      GET,
+	}
+
+	// HTML escapes the attributes and uses the title as the text.
+	body := render(MIMETextHTML).Body.String()
+	if !strings.Contains(body, `<a href="/orders/7/invoice" rel="invoice" title="Invoice &lt;PDF&gt;" type="application/pdf" hreflang="en" data-name="pdf" data-profile="https://example.com/profiles/invoice" data-deprecation="https://example.com/deprecations/invoice" data-method="GET">Invoice &lt;PDF&gt;</a>`) {
+		t.Errorf("Unexpected HTML: %s", body)
+	}
+
+	// JSON carries every attribute.
+	rr := render(MIMEAppJSON)
+	var doc struct {
+		Links []Link `json:"links"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Links) != 1 || doc.Links[0] != expected {
+		t.Errorf("Expected %+v, got %+v", expected, doc.Links)
+	}
+
+	// So does the Link header.
+	if links := ParseLinkHeader(rr.Header().Values(HeaderLink)...); len(links) != 1 || links[0] != expected {
+		t.Errorf("Expected %+v, got %+v", expected, links)
+	}
+
+	// HAL link objects have the HAL attributes.
+	hal, err := DecodeHAL(render(MIMEAppHALJSON).Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if invoice, _ := hal.Link("invoice"); invoice.Title != expected.Title || invoice.Name != "pdf" || invoice.Deprecation != expected.Deprecation || invoice.Profile != expected.Profile || invoice.Hreflang != "en" {
+		t.Errorf("Unexpected HAL link: %+v", invoice)
+	}
+
+	// JSON:API uses a link object for links with attributes.
+	jsonapi, err := DecodeJSONAPI(render(MIMEAppJSONAPI).Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if related := jsonapi.Data[0].Relationships["invoice"].Links["related"]; related.Href != expected.Href || related.Type != expected.Type || related.Profile != expected.Profile {
+		t.Errorf("Unexpected JSON:API link: %+v", related)
+	}
+}
+
+func TestConditionalLinks(t *testing.T) {
+	statuses := map[string]string{"1": "open", "2": "shipped"}
+
+	i := New()
+	i.Register("/orders/:id").GET(func(c Context) error {
+		if c.GetParamValue("id") == "1" {
+			if err := c.AddLink("https://tracking.example.com/{id}", "tracking"); err != nil {
+				return err
+			}
+		}
+		return c.Render(StatusOK, map[string]string{"status": statuses[c.GetParamValue("id")]})
+	})
+	i.Register("/orders/:id/cancel").POST(func(c Context) error { return nil })
+	i.Register("/orders/:id/edit").GET(func(c Context) error { return nil })
+	orders := i.Resource("/orders/:id")
+	orders.Link("/orders/:id/cancel", "cancel", LinkMethod(POST), LinkWhen(func(c Context) bool {
+		return statuses[c.GetParamValue("id")] == "open"
+	}))
+	orders.Link("/orders/:id/edit", "edit", LinkWhen(func(c Context) bool {
+		return c.Request().Header.Get(HeaderAuthorization) == "owner"
+	}))
+
+	rels := func(id, auth string) string {
+		req := httptest.NewRequest(GET, "/orders/"+id, nil)
+		req.Header.Set(HeaderAccept, MIMEAppJSON)
+		req.Header.Set(HeaderAuthorization, auth)
+		rr := httptest.NewRecorder()
+		i.ServeHTTP(rr, req)
+		var doc struct {
+			Links []Link `json:"links"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &doc); err != nil {
+			t.Fatal(err)
+		}
+		rels := make([]string, 0)
+		for _, link := range doc.Links {
+			rels = append(rels, link.Rel+" "+link.Href)
+		}
+		return strings.Join(rels, ", ")
+	}
+
+	for _, test := range []struct{ id, auth, expected string }{
+		{"1", "", "cancel /orders/1/cancel, tracking https://tracking.example.com/1"},
+		{"1", "owner", "cancel /orders/1/cancel, edit /orders/1/edit, tracking https://tracking.example.com/1"},
+		{"2", "owner", "edit /orders/2/edit"},
+		{"2", "", ""},
+	} {
+		if got := rels(test.id, test.auth); got != test.expected {
+			t.Errorf("Expected %q for order %s (%q), got %q", test.expected, test.id, test.auth, got)
+		}
+	}
+
+	// Links added by a handler don't change the resource.
+	if len(orders.Links()) != 2 {
+		t.Errorf("Unexpected resource links: %+v", orders.Links())
+	}
+}
+
+func TestLinkParamMapping(t *testing.T) {
+	i := New()
+	i.Register("/users/:userId/orders/:orderId").GET(func(c Context) error {
+		c.SetLinkValue("invoice", "inv-"+c.GetParamValue("orderId"))
+		return c.Render(StatusOK, nil)
+	})
+	i.Register("/users/:id").GET(func(c Context) error { return nil })
+	i.Register("/orders/:id").GET(func(c Context) error { return nil })
+	i.Register("/invoices/:id").GET(func(c Context) error { return nil })
+	i.Register("/reports/:year").GET(func(c Context) error { return nil })
+
+	orders := i.Resource("/users/:userId/orders/:orderId")
+	orders.Link("/users/:id", "owner", LinkParam("id", "userId"))
+	orders.Link("/orders/:id", "canonical", LinkParam("id", "orderId"))
+	orders.Link("/invoices/:id", "invoice", LinkValue("id", "invoice"))
+	orders.Link("/reports/:year", "report", LinkConst("year", "2024"))
+	orders.Link("/orders/:id", "unmapped")
+
+	req := httptest.NewRequest(GET, "/users/7/orders/42", nil)
+	req.Header.Set(HeaderAccept, MIMEAppJSON)
+	rr := httptest.NewRecorder()
+	i.ServeHTTP(rr, req)
+	var doc struct {
+		Links []Link `json:"links"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"owner":     "/users/7",
+		"canonical": "/orders/42",
+		"invoice":   "/invoices/inv-42",
+		"report":    "/reports/2024",
+		"unmapped":  "/orders/{id}",
+	}
+	for _, link := range doc.Links {
+		if link.Href != expected[link.Rel] {
+			t.Errorf("Expected %s link %q, got %q", link.Rel, expected[link.Rel], link.Href)
+		}
+	}
+	if len(doc.Links) != len(expected) {
+		t.Errorf("Unexpected links: %+v", doc.Links)
+	}
+}
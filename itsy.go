@@ -1,8 +1,12 @@
 package itsy
 
 import (
+	"errors"
 	"net/http"
-	_ "net/http/pprof"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"go.uber.org/zap"
 )
@@ -13,19 +17,51 @@ type (
 		router    *router             // Used to route requests to resources.
 		resources map[string]Resource // A map of resource names to resources.
 
-		Logger *zap.Logger // Uses zap for logging.
+		server     *http.Server // The HTTP server shared by all listeners.
+		serverOnce sync.Once    // Guards the creation of the server.
+		draining   atomic.Bool  // Set while a graceful shutdown is in progress.
+		health     *Health      // The health resources, if registered.
+		admin      *http.Server // The admin server, if served on a separate listener.
+		mounts     []mount      // Handlers mounted at path prefixes.
+		metrics    *Metrics     // The request metrics, if enabled.
+		tracer     *tracer      // The tracer, if tracing is enabled.
+		middleware []Middleware // The middleware applied to every handler.
+
+		transitions atomic.Pointer[transitionIndex] // The transitions by method and target, rebuilt when resources change.
+
+		representations []registeredRepresentation // The representations offered in content negotiation.
+
+		Logger      *zap.Logger     // Uses zap for logging.
+		LogLevel    zap.AtomicLevel // The level of the logger, adjustable at runtime.
+		H2C         bool            // Accept HTTP/2 on cleartext listeners, with prior knowledge or by upgrade.
+		DrainDelay  time.Duration   // How long Shutdown reports not-ready before closing listeners.
+		LinkHeaders bool            // Send the resource's links as Link headers with every successful response.
+		StrictLinks bool            // Refuse to serve if Validate finds problems, instead of logging them.
+
+		MaxEmbedDepth int // How deep embedded resources embed their own, DefaultEmbedDepth if 0.
+		MaxEmbeds     int // How many resources a response embeds at most, DefaultMaxEmbeds if 0.
 	}
 	// HandlerFunc is a function that handles a request.
 	HandlerFunc func(Context) error
 	// Middleware is a function that wraps a handler.
 	Middleware func(Context, HandlerFunc) HandlerFunc
+	// mount is an http.Handler mounted at a path prefix.
+	mount struct {
+		prefix  string
+		handler http.Handler
+	}
 )
 
 // New creates a new Itsy instance.
 func New() *Itsy {
+	level := zap.NewAtomicLevelAt(zap.DebugLevel)
 	i := &Itsy{
 		resources: make(map[string]Resource),
-		Logger:    setupLogger(),
+		Logger:    setupLogger(level),
+		LogLevel:  level,
+		H2C:       true,
+
+		representations: defaultRepresentations(),
 	}
 	i.router = newRouter(i)
 	return i
@@ -36,12 +72,25 @@ func (i *Itsy) Register(path string) Resource {
 	baseResource := newBaseResource(path, i)
 	i.resources[path] = baseResource
 	i.router.addRoute(path, baseResource)
+	i.transitions.Store(nil)
 	return baseResource
 }
 
+// Use adds middleware that wraps every handler, in the order given.
+func (i *Itsy) Use(middleware ...Middleware) {
+	i.middleware = append(i.middleware, middleware...)
+}
+
+// Mount serves requests for the path prefix and everything below it with an http.Handler.
+func (i *Itsy) Mount(prefix string, handler http.Handler) {
+	prefix = "/" + strings.Trim(prefix, "/")
+	i.mounts = append(i.mounts, mount{prefix: prefix, handler: handler})
+}
+
 // SetResource sets a resource given a path.
 func (i *Itsy) SetResource(path string, resource Resource) {
 	i.resources[path] = resource
+	i.transitions.Store(nil)
 }
 
 // Resource returns a resource given a path.
@@ -64,21 +113,20 @@ func (i *Itsy) ResourceExistsWithMethod(path, method string) bool {
 	return resource.Handler(method) != nil
 }
 
-// Run runs the Itsy instance.
+// Run runs the Itsy instance on a TCP port.
 func (i *Itsy) Run(port ...string) {
 	i.Logger.Info("Starting server...")
 
+	address := DefaultPort
 	if len(port) > 0 {
-		i.Logger.Info("Listening on port " + port[0])
-		err := http.ListenAndServe(port[0], i)
-		if err != nil {
-			i.Logger.Fatal("Server stopped", zap.Error(err))
-		}
-	} else {
-		i.Logger.Info("Listening on port " + DefaultPort)
-		err := http.ListenAndServe(DefaultPort, i)
-		if err != nil {
-			i.Logger.Fatal("Server stopped", zap.Error(err))
-		}
+		address = port[0]
+	}
+
+	l, err := TCPListener(address)
+	if err != nil {
+		i.Logger.Fatal("Server stopped", zap.Error(err))
+	}
+	if err := i.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		i.Logger.Fatal("Server stopped", zap.Error(err))
 	}
 }
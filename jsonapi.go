@@ -0,0 +1,348 @@
+package itsy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// MIMEAppJSONAPI is the JSON:API media type.
+const MIMEAppJSONAPI = "application/vnd.api+json"
+
+type (
+	// JSONAPIIdentifiable is implemented by data that knows its JSON:API type and id.
+	// Other data takes its type from the resource path and its id from the "id" parameter or property.
+	JSONAPIIdentifiable interface {
+		JSONAPIType() string // The type of the resource object.
+		JSONAPIID() string   // The id of the resource object.
+	}
+	// JSONAPIMetaProvider is implemented by data with top-level meta information.
+	JSONAPIMetaProvider interface {
+		JSONAPIMeta() map[string]interface{} // The meta object of the document.
+	}
+	// JSONAPIDocument is a JSON:API top-level document, as encoded and decoded.
+	JSONAPIDocument struct {
+		Data     []JSONAPIResourceObject // The primary data.
+		Many     bool                    // Whether the primary data is an array.
+		Included []JSONAPIResourceObject // The included resources.
+		Links    map[string]string       // The top-level links.
+		Meta     map[string]interface{}  // The top-level meta information.
+		Errors   []JSONAPIError          // The errors.
+	}
+	// JSONAPIResourceObject is a JSON:API resource object.
+	JSONAPIResourceObject struct {
+		Type          string                         `json:"type"`                    // The type of the resource.
+		ID            string                         `json:"id,omitempty"`            // The id of the resource, empty for new resources.
+		Attributes    map[string]interface{}         `json:"attributes,omitempty"`    // The resource's state.
+		Relationships map[string]JSONAPIRelationship `json:"relationships,omitempty"` // The related resources by link relation.
+		Links         map[string]string              `json:"links,omitempty"`         // The links of the resource.
+	}
+	// JSONAPIRelationship is a relationship of a JSON:API resource object.
+	JSONAPIRelationship struct {
+		Links map[string]JSONAPILink `json:"links,omitempty"` // The links of the relationship.
+		Data  json.RawMessage        `json:"data,omitempty"`  // The resource linkage: an identifier, an array of them or null.
+	}
+	// JSONAPILink is a JSON:API link, encoded as a string unless it has target attributes.
+	JSONAPILink struct {
+		Href     string `json:"href"`               // The URL of the linked resource.
+		Title    string `json:"title,omitempty"`    // A human-readable label of the link.
+		Type     string `json:"type,omitempty"`     // The media type of the linked resource.
+		Hreflang string `json:"hreflang,omitempty"` // The language of the linked resource.
+		Profile  string `json:"-"`                  // The profile of the linked resource, encoded as describedby.
+	}
+	// JSONAPIIdentifier identifies a JSON:API resource object.
+	JSONAPIIdentifier struct {
+		Type string `json:"type"` // The type of the resource.
+		ID   string `json:"id"`   // The id of the resource.
+	}
+	// JSONAPIError is a JSON:API error object.
+	JSONAPIError struct {
+		Status string `json:"status"`           // The HTTP status code, as a string.
+		Title  string `json:"title"`            // The status text.
+		Detail string `json:"detail,omitempty"` // The message of the error.
+	}
+	// jsonapiDocument is the wire format of a JSONAPIDocument.
+	jsonapiDocument struct {
+		Data     interface{}             `json:"data,omitempty"`
+		Included []JSONAPIResourceObject `json:"included,omitempty"`
+		Links    map[string]string       `json:"links,omitempty"`
+		Meta     map[string]interface{}  `json:"meta,omitempty"`
+		Errors   []JSONAPIError          `json:"errors,omitempty"`
+	}
+	// jsonapiEncoder renders a representation as a JSON:API document.
+	jsonapiEncoder struct{}
+)
+
+// Encode writes the representation as a JSON:API document.
+func (jsonapiEncoder) Encode(w io.Writer, rep *Representation) error {
+	doc, err := newJSONAPIDocument(rep)
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(doc)
+}
+
+// EncodeError writes the error as a JSON:API error document.
+func (jsonapiEncoder) EncodeError(w io.Writer, err *HTTPError) error {
+	return json.NewEncoder(w).Encode(JSONAPIDocument{Errors: []JSONAPIError{{
+		Status: strconv.Itoa(err.Status),
+		Title:  err.Title(),
+		Detail: err.Message,
+	}}})
+}
+
+// newJSONAPIDocument creates the JSON:API document of a representation.
+// Slices become an array of resource objects; embedded representations become included resources.
+func newJSONAPIDocument(rep *Representation) (JSONAPIDocument, error) {
+	doc := JSONAPIDocument{Links: map[string]string{"self": rep.Self}}
+	if meta, ok := rep.Data.(JSONAPIMetaProvider); ok {
+		doc.Meta = meta.JSONAPIMeta()
+	}
+
+	if v := reflect.ValueOf(rep.Data); v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		doc.Many = true
+		doc.Data = make([]JSONAPIResourceObject, 0, v.Len())
+		for n := 0; n < v.Len(); n++ {
+			object, err := newJSONAPIResourceObject(rep, v.Index(n).Interface())
+			if err != nil {
+				return JSONAPIDocument{}, err
+			}
+			doc.Data = append(doc.Data, object)
+		}
+		return doc, nil
+	}
+
+	if rep.Data == nil {
+		return doc, nil
+	}
+	object, err := newJSONAPIResourceObject(rep, rep.Data)
+	if err != nil {
+		return JSONAPIDocument{}, err
+	}
+	if object.ID == "" && rep.Context != nil {
+		object.ID, _ = paramValue(rep.Context, "id")
+	}
+	object.Links = map[string]string{"self": rep.Self}
+
+	for _, link := range rep.Hypermedia.Links {
+		if _, ok := object.Relationships[link.Rel]; ok {
+			continue
+		}
+		if object.Relationships == nil {
+			object.Relationships = make(map[string]JSONAPIRelationship)
+		}
+		object.Relationships[link.Rel] = JSONAPIRelationship{Links: map[string]JSONAPILink{"related": {
+			Href:     link.Href,
+			Title:    link.Title,
+			Type:     link.Type,
+			Hreflang: link.Hreflang,
+			Profile:  link.Profile,
+		}}}
+	}
+
+	for _, rel := range sortedRels(rep.Embedded) {
+		identifiers := make([]JSONAPIIdentifier, 0, len(rep.Embedded[rel]))
+		for _, embedded := range rep.Embedded[rel] {
+			included, err := newJSONAPIResourceObject(embedded, embedded.Data)
+			if err != nil {
+				return JSONAPIDocument{}, err
+			}
+			included.Links = map[string]string{"self": embedded.Self}
+			doc.Included = append(doc.Included, included)
+			identifiers = append(identifiers, JSONAPIIdentifier{Type: included.Type, ID: included.ID})
+		}
+
+		linkage, err := json.Marshal(oneOrMany(identifiers))
+		if err != nil {
+			return JSONAPIDocument{}, err
+		}
+		if object.Relationships == nil {
+			object.Relationships = make(map[string]JSONAPIRelationship)
+		}
+		relationship := object.Relationships[rel]
+		relationship.Data = linkage
+		object.Relationships[rel] = relationship
+	}
+
+	doc.Data = []JSONAPIResourceObject{object}
+	return doc, nil
+}
+
+// newJSONAPIResourceObject creates the resource object of a value of the representation.
+func newJSONAPIResourceObject(rep *Representation, value interface{}) (JSONAPIResourceObject, error) {
+	attributes, err := objectProperties(value)
+	if err != nil {
+		return JSONAPIResourceObject{}, err
+	}
+
+	object := JSONAPIResourceObject{}
+	if identifiable, ok := value.(JSONAPIIdentifiable); ok {
+		object.Type, object.ID = identifiable.JSONAPIType(), identifiable.JSONAPIID()
+	} else {
+		object.Type = jsonapiType(rep.Resource)
+		if id, ok := attributes["id"]; ok {
+			object.ID = fmt.Sprint(id)
+		}
+	}
+
+	delete(attributes, "id")
+	delete(attributes, "type")
+	if len(attributes) > 0 {
+		object.Attributes = attributes
+	}
+	return object, nil
+}
+
+// jsonapiType derives a resource type from the last static segment of the resource's path.
+func jsonapiType(r Resource) string {
+	if r == nil {
+		return ""
+	}
+	segments := strings.Split(strings.Trim(r.Path(), "/"), "/")
+	for n := len(segments) - 1; n >= 0; n-- {
+		if segments[n] != "" && !strings.HasPrefix(segments[n], ":") {
+			return segments[n]
+		}
+	}
+	return ""
+}
+
+// MarshalJSON encodes the document, with single primary data as an object.
+func (d JSONAPIDocument) MarshalJSON() ([]byte, error) {
+	doc := jsonapiDocument{Included: d.Included, Links: d.Links, Meta: d.Meta, Errors: d.Errors}
+	switch {
+	case d.Many:
+		doc.Data = d.Data
+	case len(d.Data) > 0:
+		doc.Data = d.Data[0]
+	case d.Errors == nil:
+		doc.Data = json.RawMessage("null")
+	}
+	return json.Marshal(doc)
+}
+
+// UnmarshalJSON decodes the document, accepting a single resource object or an array as primary data.
+func (d *JSONAPIDocument) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Data     json.RawMessage         `json:"data"`
+		Included []JSONAPIResourceObject `json:"included"`
+		Links    map[string]string       `json:"links"`
+		Meta     map[string]interface{}  `json:"meta"`
+		Errors   []JSONAPIError          `json:"errors"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*d = JSONAPIDocument{Included: raw.Included, Links: raw.Links, Meta: raw.Meta, Errors: raw.Errors}
+
+	if len(raw.Data) == 0 || string(raw.Data) == "null" {
+		return nil
+	}
+	d.Many = strings.HasPrefix(strings.TrimSpace(string(raw.Data)), "[")
+	return unmarshalOneOrMany(raw.Data, func(item json.RawMessage) error {
+		var object JSONAPIResourceObject
+		if err := json.Unmarshal(item, &object); err != nil {
+			return err
+		}
+		d.Data = append(d.Data, object)
+		return nil
+	})
+}
+
+// MarshalJSON encodes the link as a string, or as a link object if it has target attributes.
+func (l JSONAPILink) MarshalJSON() ([]byte, error) {
+	if l.Title == "" && l.Type == "" && l.Hreflang == "" && l.Profile == "" {
+		return json.Marshal(l.Href)
+	}
+	type linkObject JSONAPILink
+	object := struct {
+		linkObject
+		DescribedBy string `json:"describedby,omitempty"`
+	}{linkObject(l), l.Profile}
+	return json.Marshal(object)
+}
+
+// UnmarshalJSON decodes a link string or link object.
+func (l *JSONAPILink) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &l.Href); err == nil {
+		return nil
+	}
+	type linkObject JSONAPILink
+	var object struct {
+		linkObject
+		DescribedBy string `json:"describedby"`
+	}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return err
+	}
+	*l = JSONAPILink(object.linkObject)
+	l.Profile = object.DescribedBy
+	return nil
+}
+
+// Identifiers returns the resource linkage of the relationship.
+func (r JSONAPIRelationship) Identifiers() ([]JSONAPIIdentifier, error) {
+	identifiers := make([]JSONAPIIdentifier, 0)
+	if len(r.Data) == 0 || string(r.Data) == "null" {
+		return identifiers, nil
+	}
+	err := unmarshalOneOrMany(r.Data, func(item json.RawMessage) error {
+		var identifier JSONAPIIdentifier
+		if err := json.Unmarshal(item, &identifier); err != nil {
+			return err
+		}
+		identifiers = append(identifiers, identifier)
+		return nil
+	})
+	return identifiers, err
+}
+
+// DecodeJSONAPI decodes a JSON:API document, e.g. a response body in a test or client.
+func DecodeJSONAPI(r io.Reader) (*JSONAPIDocument, error) {
+	doc := &JSONAPIDocument{}
+	if err := json.NewDecoder(r).Decode(doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// BindJSONAPI parses a JSON:API request body, such as that of a POST or PATCH, and decodes
+// the attributes of its single resource object into v. The returned errors are HTTP errors
+// that a handler can return as they are.
+func BindJSONAPI(c Context, v interface{}) (*JSONAPIResourceObject, error) {
+	req := c.Request()
+	if mediaType(req.Header.Get(HeaderContentType)) != MIMEAppJSONAPI {
+		return nil, NewHTTPError(StatusUnsupportedMediaType, "Expected "+MIMEAppJSONAPI)
+	}
+
+	doc, err := DecodeJSONAPI(req.Body)
+	if err != nil {
+		return nil, NewHTTPError(StatusBadRequest, "Malformed JSON:API document")
+	}
+	if doc.Many || len(doc.Data) != 1 {
+		return nil, NewHTTPError(StatusBadRequest, "Expected a single resource object")
+	}
+	object := &doc.Data[0]
+	if object.Type == "" {
+		return nil, NewHTTPError(StatusBadRequest, "Resource object has no type")
+	}
+
+	if v != nil && object.Attributes != nil {
+		attributes, err := json.Marshal(object.Attributes)
+		if err == nil {
+			err = json.Unmarshal(attributes, v)
+		}
+		if err != nil {
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &typeErr) {
+				return nil, NewHTTPError(StatusBadRequest, "Invalid attribute "+typeErr.Field)
+			}
+			return nil, NewHTTPError(StatusBadRequest, "Invalid attributes")
+		}
+	}
+	return object, nil
+}
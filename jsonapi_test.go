@@ -0,0 +1,152 @@
+package itsy
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type jsonapiArticle struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+func (a jsonapiArticle) JSONAPIType() string { return "articles" }
+func (a jsonapiArticle) JSONAPIID() string   { return a.ID }
+
+type jsonapiArticles []jsonapiArticle
+
+func (a jsonapiArticles) JSONAPIMeta() map[string]interface{} {
+	return map[string]interface{}{"total": len(a)}
+}
+
+func TestJSONAPI(t *testing.T) {
+	i := New()
+	i.Register("/orders/:id").GET(func(c Context) error {
+		return c.Render(StatusOK, map[string]interface{}{"total": 9.5})
+	})
+	i.Register("/orders/:id/customer").GET(func(c Context) error {
+		return c.Render(StatusOK, nil)
+	})
+	i.Register("/articles").GET(func(c Context) error {
+		return c.Render(StatusOK, jsonapiArticles{{ID: "1", Title: "One"}, {ID: "2", Title: "Two"}})
+	})
+	i.Resource("/orders/:id").Link("/orders/:id/customer", "customer")
+
+	get := func(path string) *JSONAPIDocument {
+		req := httptest.NewRequest(GET, path, nil)
+		req.Header.Set(HeaderAccept, MIMEAppJSONAPI)
+		rr := httptest.NewRecorder()
+		i.ServeHTTP(rr, req)
+		if rr.Header().Get(HeaderContentType) != MIMEAppJSONAPI {
+			t.Fatalf("Unexpected content type: %q", rr.Header().Get(HeaderContentType))
+		}
+		doc, err := DecodeJSONAPI(rr.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return doc
+	}
+
+	// A single resource takes its type from the path, its id from the parameter and relationships from its links.
+	doc := get("/orders/42")
+	if doc.Many || len(doc.Data) != 1 || doc.Links["self"] != "/orders/42" {
+		t.Fatalf("Unexpected document: %+v", doc)
+	}
+	order := doc.Data[0]
+	if order.Type != "orders" || order.ID != "42" || order.Attributes["total"] != 9.5 || order.Links["self"] != "/orders/42" {
+		t.Errorf("Unexpected resource object: %+v", order)
+	}
+	if order.Relationships["customer"].Links["related"].Href != "/orders/42/customer" {
+		t.Errorf("Unexpected relationships: %+v", order.Relationships)
+	}
+
+	// Collections are arrays of resource objects with top-level meta.
+	doc = get("/articles")
+	if !doc.Many || len(doc.Data) != 2 || doc.Data[1].Type != "articles" || doc.Data[1].ID != "2" || doc.Data[1].Attributes["title"] != "Two" {
+		t.Errorf("Unexpected collection: %+v", doc.Data)
+	}
+	if doc.Meta["total"] != 2.0 {
+		t.Errorf("Unexpected meta: %v", doc.Meta)
+	}
+
+	// Errors use the error format.
+	doc = get("/missing")
+	if len(doc.Errors) != 1 || doc.Errors[0].Status != "404" || doc.Errors[0].Title != "Not Found" {
+		t.Errorf("Unexpected errors: %+v", doc.Errors)
+	}
+}
+
+func TestJSONAPIIncluded(t *testing.T) {
+	i := New()
+	i.Register("/articles/:id").GET(func(c Context) error {
+		return nil
+	})
+	c := i.prepareRequestContext(httptest.NewRecorder(), httptest.NewRequest(GET, "/articles/1", nil), "/articles/:id")
+	c.AddParam("id", "1")
+
+	rep := newRepresentation(c, jsonapiArticle{ID: "1", Title: "One"})
+	author := newRepresentation(c, map[string]string{"id": "9", "name": "Ada"})
+	author.Self = "/people/9"
+	rep.Embedded["author"] = []*Representation{author}
+
+	var b bytes.Buffer
+	if err := (jsonapiEncoder{}).Encode(&b, rep); err != nil {
+		t.Fatal(err)
+	}
+	doc, err := DecodeJSONAPI(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	identifiers, err := doc.Data[0].Relationships["author"].Identifiers()
+	if err != nil || len(identifiers) != 1 || identifiers[0].ID != "9" {
+		t.Errorf("Unexpected linkage: %+v %v", identifiers, err)
+	}
+	if len(doc.Included) != 1 || doc.Included[0].Attributes["name"] != "Ada" || doc.Included[0].Links["self"] != "/people/9" {
+		t.Errorf("Unexpected included resources: %+v", doc.Included)
+	}
+}
+
+func TestBindJSONAPI(t *testing.T) {
+	i := New()
+	var created jsonapiArticle
+	i.Register("/articles").POST(func(c Context) error {
+		object, err := BindJSONAPI(c, &created)
+		if err != nil {
+			return err
+		}
+		created.ID = "3"
+		if object.Type != "articles" {
+			return NewHTTPError(StatusBadRequest, "Unexpected type")
+		}
+		return c.Render(StatusOK, created)
+	})
+
+	post := func(contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(POST, "/articles", strings.NewReader(body))
+		req.Header.Set(HeaderContentType, contentType)
+		req.Header.Set(HeaderAccept, MIMEAppJSONAPI)
+		rr := httptest.NewRecorder()
+		i.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := post(MIMEAppJSONAPI, `{"data": {"type": "articles", "attributes": {"title": "Three"}}}`)
+	if rr.Code != StatusOK || created.Title != "Three" {
+		t.Errorf("Unexpected response: %d %s", rr.Code, rr.Body.String())
+	}
+
+	// Other media types and malformed documents are rejected with JSON:API errors.
+	for contentType, body := range map[string]string{
+		MIMEAppJSON:    `{"data": {"type": "articles"}}`,
+		MIMEAppJSONAPI: `{"data": [{"type": "articles"}]}`,
+	} {
+		rr = post(contentType, body)
+		doc, err := DecodeJSONAPI(rr.Body)
+		if err != nil || rr.Code < 400 || len(doc.Errors) != 1 {
+			t.Errorf("Unexpected response for %s %s: %d %+v", contentType, body, rr.Code, doc)
+		}
+	}
+}
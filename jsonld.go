@@ -0,0 +1,214 @@
+package itsy
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+)
+
+const (
+	// MIMEAppLDJSON is the JSON-LD media type.
+	MIMEAppLDJSON = "application/ld+json"
+	// HydraNamespace is the IRI of the Hydra core vocabulary.
+	HydraNamespace = "http://www.w3.org/ns/hydra/core#"
+	// DocumentationPath is the default path of the Hydra API documentation.
+	DocumentationPath = "/docs"
+)
+
+type (
+	// JSONLDConfig configures the JSON-LD representation.
+	JSONLDConfig struct {
+		Vocab             string                 // The IRI that terms and link relations are relative to, by default the documentation's.
+		Rels              map[string]string      // The IRIs of link relations that don't belong to the vocabulary.
+		Context           map[string]interface{} // Terms added to the generated @context, replacing generated ones.
+		DocumentationPath string                 // The path of the Hydra API documentation, DocumentationPath by default.
+	}
+	// jsonldEncoder renders a representation as a JSON-LD node.
+	jsonldEncoder struct {
+		itsy   *Itsy
+		config JSONLDConfig
+	}
+)
+
+// JSONLD registers the JSON-LD representation and serves the Hydra API documentation as an
+// operational resource. JSON-LD responses link to the documentation with a Link header.
+// An error is returned, and nothing registered, if a resource is already registered at the
+// documentation's path.
+func (i *Itsy) JSONLD(config JSONLDConfig) error {
+	if config.DocumentationPath == "" {
+		config.DocumentationPath = DocumentationPath
+	}
+	if i.Resource(config.DocumentationPath) != nil {
+		return errors.New("resource " + config.DocumentationPath + " is already registered")
+	}
+	if config.Vocab == "" {
+		config.Vocab = config.DocumentationPath + "#"
+	}
+	encoder := &jsonldEncoder{itsy: i, config: config}
+	i.RegisterRepresentation(MIMEAppLDJSON, encoder)
+
+	docs := i.Register(config.DocumentationPath)
+	docs.GET(func(c Context) error {
+		c.Response().Header().Set(HeaderContentType, MIMEAppLDJSON)
+		return json.NewEncoder(c.Response()).Encode(encoder.apiDocumentation())
+	})
+	docs.(OperationalMarker).Operational()
+
+	link := "<" + config.DocumentationPath + `>; rel="` + HydraNamespace + `apiDocumentation"`
+	i.Use(func(c Context, next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			res := c.Response()
+			res.Before(func() {
+				if mediaType(res.Header().Get(HeaderContentType)) == MIMEAppLDJSON {
+					res.Header().Add(HeaderLink, link)
+				}
+			})
+			return next(c)
+		}
+	})
+	return nil
+}
+
+// Encode writes the representation as a JSON-LD node with its @context.
+func (e *jsonldEncoder) Encode(w io.Writer, rep *Representation) error {
+	node, err := e.node(rep)
+	if err != nil {
+		return err
+	}
+	node["@context"] = e.context()
+	return json.NewEncoder(w).Encode(node)
+}
+
+// EncodeError writes the error as a Hydra error.
+func (e *jsonldEncoder) EncodeError(w io.Writer, err *HTTPError) error {
+	return json.NewEncoder(w).Encode(map[string]interface{}{
+		"@context":          e.context(),
+		"@type":             "hydra:Error",
+		"hydra:statusCode":  err.Status,
+		"hydra:title":       err.Title(),
+		"hydra:description": err.Message,
+	})
+}
+
+// node creates the JSON-LD node of a representation, with links as IRI-valued properties
+// and embedded representations as nested nodes.
+func (e *jsonldEncoder) node(rep *Representation) (map[string]interface{}, error) {
+	node, err := objectProperties(rep.Data)
+	if err != nil {
+		return nil, err
+	}
+	node["@id"] = rep.Self
+	if rep.Resource != nil {
+		node["@type"] = e.class(rep.Resource.Path())
+	}
+
+	links := make(map[string][]string)
+	for _, link := range rep.Hypermedia.Links {
+		links[link.Rel] = append(links[link.Rel], link.Href)
+	}
+	for rel, hrefs := range links {
+		node[rel] = oneOrMany(hrefs)
+	}
+
+	for rel, embedded := range rep.Embedded {
+		nodes := make([]map[string]interface{}, 0, len(embedded))
+		for _, r := range embedded {
+			n, err := e.node(r)
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, n)
+		}
+		node[rel] = oneOrMany(nodes)
+	}
+	return node, nil
+}
+
+// context creates the @context: the vocabulary, the Hydra prefix and an IRI term for every link relation.
+func (e *jsonldEncoder) context() map[string]interface{} {
+	context := map[string]interface{}{
+		"@vocab": e.config.Vocab,
+		"hydra":  HydraNamespace,
+	}
+	for _, resource := range e.itsy.resources {
+		for _, link := range resource.Links() {
+			context[link.Rel] = map[string]interface{}{"@id": e.rel(link.Rel), "@type": "@id"}
+		}
+	}
+	for term, definition := range e.config.Context {
+		context[term] = definition
+	}
+	return context
+}
+
+// rel returns the IRI of a link relation.
+func (e *jsonldEncoder) rel(rel string) string {
+	if iri, ok := e.config.Rels[rel]; ok {
+		return iri
+	}
+	return e.config.Vocab + rel
+}
+
+// class returns the IRI of the Hydra class of a resource.
+func (e *jsonldEncoder) class(path string) string {
+	return e.config.DocumentationPath + "#" + path
+}
+
+// apiDocumentation describes every resource as a Hydra class, with its methods as supported
+// operations and its links as supported properties ranging over the linked resources' classes.
+func (e *jsonldEncoder) apiDocumentation() map[string]interface{} {
+	paths := make([]string, 0, len(e.itsy.resources))
+	for path := range e.itsy.resources {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	classes := make([]map[string]interface{}, 0, len(paths))
+	for _, path := range paths {
+		resource := e.itsy.resources[path]
+
+		operations := make([]map[string]interface{}, 0)
+		for _, method := range resourceMethods(resource) {
+			operations = append(operations, map[string]interface{}{
+				"@type":        "hydra:Operation",
+				"hydra:method": method,
+			})
+		}
+
+		properties := make([]map[string]interface{}, 0)
+		for _, link := range resource.Links() {
+			property := map[string]interface{}{
+				"@id":   e.rel(link.Rel),
+				"@type": "hydra:Link",
+			}
+			if target := e.itsy.linkTarget(link.Href); target != nil {
+				property["hydra:range"] = e.class(target.Path())
+			}
+			properties = append(properties, map[string]interface{}{
+				"@type":          "hydra:SupportedProperty",
+				"hydra:property": property,
+				"hydra:title":    link.Rel,
+			})
+		}
+
+		classes = append(classes, map[string]interface{}{
+			"@id":                      e.class(path),
+			"@type":                    "hydra:Class",
+			"hydra:title":              path,
+			"hydra:supportedOperation": operations,
+			"hydra:supportedProperty":  properties,
+		})
+	}
+
+	doc := map[string]interface{}{
+		"@context":             e.context(),
+		"@id":                  e.config.DocumentationPath,
+		"@type":                "hydra:ApiDocumentation",
+		"hydra:supportedClass": classes,
+	}
+	if e.itsy.ResourceExists("/") {
+		doc["hydra:entrypoint"] = "/"
+	}
+	return doc
+}
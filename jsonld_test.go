@@ -0,0 +1,102 @@
+package itsy
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestJSONLD(t *testing.T) {
+	i := New()
+	i.Register("/orders/:id").GET(func(c Context) error {
+		return c.Render(StatusOK, map[string]interface{}{"total": 9.5})
+	})
+	i.Resource("/orders/:id").DELETE(func(c Context) error { return nil })
+	i.Register("/customers/:id").GET(func(c Context) error {
+		return c.Render(StatusOK, nil)
+	})
+	i.Resource("/orders/:id").Link("/customers/:id", "customer")
+	i.Resource("/orders/:id").Link("/orders/:id", "canonical")
+	if err := i.JSONLD(JSONLDConfig{
+		Vocab:   "https://example.com/vocab#",
+		Rels:    map[string]string{"canonical": "http://www.iana.org/assignments/relation/canonical"},
+		Context: map[string]interface{}{"total": "https://schema.org/totalPrice"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(path string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		req := httptest.NewRequest(GET, path, nil)
+		req.Header.Set(HeaderAccept, MIMEAppLDJSON)
+		rr := httptest.NewRecorder()
+		i.ServeHTTP(rr, req)
+		var node map[string]interface{}
+		if err := json.Unmarshal(rr.Body.Bytes(), &node); err != nil {
+			t.Fatal(err)
+		}
+		return rr, node
+	}
+
+	rr, node := get("/orders/42")
+	if rr.Header().Get(HeaderContentType) != MIMEAppLDJSON {
+		t.Fatalf("Unexpected content type: %q", rr.Header().Get(HeaderContentType))
+	}
+	if rr.Header().Get(HeaderLink) != `</docs>; rel="http://www.w3.org/ns/hydra/core#apiDocumentation"` {
+		t.Errorf("Unexpected Link header: %q", rr.Header().Get(HeaderLink))
+	}
+	if node["@id"] != "/orders/42" || node["@type"] != "/docs#/orders/:id" || node["total"] != 9.5 || node["customer"] != "/customers/42" {
+		t.Errorf("Unexpected node: %v", node)
+	}
+
+	// The context maps link relations to IRIs and includes the configured terms.
+	context := node["@context"].(map[string]interface{})
+	customer := context["customer"].(map[string]interface{})
+	canonical := context["canonical"].(map[string]interface{})
+	if context["@vocab"] != "https://example.com/vocab#" || customer["@id"] != "https://example.com/vocab#customer" || customer["@type"] != "@id" {
+		t.Errorf("Unexpected context: %v", context)
+	}
+	if canonical["@id"] != "http://www.iana.org/assignments/relation/canonical" || context["total"] != "https://schema.org/totalPrice" {
+		t.Errorf("Unexpected context: %v", context)
+	}
+
+	// The API documentation describes the resources, their operations and links.
+	_, doc := get("/docs")
+	if doc["@type"] != "hydra:ApiDocumentation" {
+		t.Fatalf("Unexpected documentation: %v", doc)
+	}
+	var orders map[string]interface{}
+	for _, class := range doc["hydra:supportedClass"].([]interface{}) {
+		if class := class.(map[string]interface{}); class["@id"] == "/docs#/orders/:id" {
+			orders = class
+		}
+	}
+	if orders == nil {
+		t.Fatalf("Orders class missing: %v", doc)
+	}
+	operations := orders["hydra:supportedOperation"].([]interface{})
+	if len(operations) != 2 || operations[1].(map[string]interface{})["hydra:method"] != DELETE {
+		t.Errorf("Unexpected operations: %v", operations)
+	}
+	property := orders["hydra:supportedProperty"].([]interface{})[0].(map[string]interface{})["hydra:property"].(map[string]interface{})
+	if property["@id"] != "https://example.com/vocab#customer" || property["hydra:range"] != "/docs#/customers/:id" {
+		t.Errorf("Unexpected property: %v", property)
+	}
+}
+
+func TestJSONLDDocumentationPathTaken(t *testing.T) {
+	i := New()
+	i.Register(DocumentationPath).GET(func(c Context) error { return c.WriteString("manual") })
+
+	// The documentation doesn't replace an existing resource.
+	if err := i.JSONLD(JSONLDConfig{}); err == nil {
+		t.Fatal("Expected an error for the registered documentation path")
+	}
+	rr := httptest.NewRecorder()
+	i.ServeHTTP(rr, httptest.NewRequest(GET, DocumentationPath, nil))
+	if rr.Body.String() != "manual" {
+		t.Errorf("Expected the existing resource, got %q", rr.Body.String())
+	}
+	if len(i.Representations()) != len(defaultRepresentations()) {
+		t.Errorf("Expected JSON-LD not to be registered, got %v", i.Representations())
+	}
+}
@@ -0,0 +1,162 @@
+package itsy
+
+import "strings"
+
+// addLinkHeaders sends the resource's links as Link headers, once the response is known to succeed.
+func (i *Itsy) addLinkHeaders(c Context) {
+	res := c.Response()
+	res.Before(func() {
+		if res.StatusCode >= StatusBadRequest {
+			return
+		}
+		if header := FormatLinkHeader(responseLinks(c)); header != "" {
+			res.Header().Add(HeaderLink, header)
+		}
+	})
+}
+
+// FormatLinkHeader formats links as the value of an RFC 8288 Link header.
+// Templated links are left out, since a Link header can only carry URI references.
+func FormatLinkHeader(links []Link) string {
+	values := make([]string, 0, len(links))
+	for _, link := range links {
+		if link.Templated {
+			continue
+		}
+		value := "<" + link.Href + ">; rel=" + quoteLinkParam(link.Rel)
+		if link.Title != "" {
+			value += "; title=" + quoteLinkParam(link.Title)
+		}
+		for _, param := range [][2]string{
+			{"type", link.Type},
+			{"hreflang", link.Hreflang},
+			{"name", link.Name},
+			{"profile", link.Profile},
+			{"deprecation", link.Deprecation},
+			{"method", link.Method},
+		} {
+			if param[1] != "" {
+				value += "; " + param[0] + "=" + quoteLinkParam(param[1])
+			}
+		}
+		values = append(values, value)
+	}
+	return strings.Join(values, ", ")
+}
+
+// ParseLinkHeader parses the values of Link headers. A link with several relation types
+// becomes one link per relation; links without a relation type are skipped.
+func ParseLinkHeader(values ...string) []Link {
+	links := make([]Link, 0)
+	for _, value := range values {
+		for _, part := range splitLinkHeader(value) {
+			part = strings.TrimSpace(part)
+			if !strings.HasPrefix(part, "<") {
+				continue
+			}
+			end := strings.Index(part, ">")
+			if end < 0 {
+				continue
+			}
+			link := Link{Href: part[1:end]}
+
+			var rels []string
+			for _, param := range splitUnquoted(part[end+1:], ';') {
+				name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+				value = unquoteLinkParam(strings.TrimSpace(value))
+				switch strings.ToLower(strings.TrimSpace(name)) {
+				case "rel":
+					if rels == nil {
+						rels = strings.Fields(value)
+					}
+				case "title":
+					link.Title = value
+				case "type":
+					link.Type = value
+				case "hreflang":
+					link.Hreflang = value
+				case "name":
+					link.Name = value
+				case "profile":
+					link.Profile = value
+				case "deprecation":
+					link.Deprecation = value
+				case "method":
+					link.Method = value
+				}
+			}
+			for _, rel := range rels {
+				link.Rel = rel
+				links = append(links, link)
+			}
+		}
+	}
+	return links
+}
+
+// splitLinkHeader splits a Link header value into link values, ignoring commas in URIs and quoted strings.
+func splitLinkHeader(value string) []string {
+	parts := make([]string, 0)
+	inURI, inQuote, escaped, start := false, false, false, 0
+	for n, r := range value {
+		switch {
+		case escaped:
+			escaped = false
+		case inQuote && r == '\\':
+			escaped = true
+		case r == '"' && !inURI:
+			inQuote = !inQuote
+		case r == '<' && !inQuote:
+			inURI = true
+		case r == '>' && !inQuote:
+			inURI = false
+		case r == ',' && !inURI && !inQuote:
+			parts = append(parts, value[start:n])
+			start = n + 1
+		}
+	}
+	return append(parts, value[start:])
+}
+
+// splitUnquoted splits s at sep outside of quoted strings.
+func splitUnquoted(s string, sep rune) []string {
+	parts := make([]string, 0)
+	inQuote, escaped, start := false, false, 0
+	for n, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case inQuote && r == '\\':
+			escaped = true
+		case r == '"':
+			inQuote = !inQuote
+		case r == sep && !inQuote:
+			parts = append(parts, s[start:n])
+			start = n + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// quoteLinkParam quotes a link parameter value.
+func quoteLinkParam(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+// unquoteLinkParam unquotes a link parameter value, if it is quoted.
+func unquoteLinkParam(value string) string {
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return value
+	}
+	var b strings.Builder
+	escaped := false
+	for _, r := range value[1 : len(value)-1] {
+		if !escaped && r == '\\' {
+			escaped = true
+			continue
+		}
+		escaped = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
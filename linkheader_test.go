@@ -0,0 +1,75 @@
+package itsy
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestLinkHeaders(t *testing.T) {
+	i := New()
+	i.LinkHeaders = true
+	i.Register("/orders/:id").GET(func(c Context) error {
+		return c.Render(StatusOK, map[string]string{"status": "open"})
+	})
+	i.Register("/customers/:customer").GET(func(c Context) error {
+		return c.Render(StatusOK, nil)
+	})
+	i.Register("/orders/:id/items").GET(func(c Context) error {
+		return c.Render(StatusOK, nil)
+	})
+	orders := i.Resource("/orders/:id")
+	orders.Link("/orders/:id/items", "items")
+	orders.Link("/customers/:customer", "customer")
+	orders.Hypermedia().Links[0].Title = `Order "items"`
+	orders.Hypermedia().Links[0].Type = MIMEAppJSON
+
+	for _, method := range []string{GET, HEAD} {
+		req := httptest.NewRequest(method, "/orders/42", nil)
+		req.Header.Set(HeaderAccept, MIMEAppJSON)
+		rr := httptest.NewRecorder()
+		i.ServeHTTP(rr, req)
+
+		// Parameters are resolved and templated links are left out.
+		expected := `</orders/42/items>; rel="items"; title="Order \"items\""; type="application/json"`
+		if got := rr.Header().Get(HeaderLink); got != expected {
+			t.Errorf("Expected Link header %s for %s, got %s", expected, method, got)
+		}
+		if method == HEAD && (rr.Code != StatusOK || rr.Body.Len() != 0) {
+			t.Errorf("Unexpected HEAD response: %d %q", rr.Code, rr.Body.String())
+		}
+	}
+
+	// Errors have no links.
+	i.Register("/broken").GET(func(c Context) error {
+		return NewHTTPError(StatusBadRequest, "Broken")
+	})
+	i.Resource("/broken").Link("/orders/:id/items", "items")
+	rr := httptest.NewRecorder()
+	i.ServeHTTP(rr, httptest.NewRequest(GET, "/broken", nil))
+	if rr.Header().Get(HeaderLink) != "" {
+		t.Errorf("Unexpected Link header: %s", rr.Header().Get(HeaderLink))
+	}
+}
+
+func TestParseLinkHeader(t *testing.T) {
+	links := ParseLinkHeader(
+		`</orders?page=2>; rel="next"; title="Next, then \"more\"", </orders?a=1,2>; rel="last first"`,
+		`<https://example.com/docs>;rel=help;type="text/html"`,
+		`<ignored>; title="no rel"`,
+	)
+	expected := []Link{
+		{Href: "/orders?page=2", Rel: "next", Title: `Next, then "more"`},
+		{Href: "/orders?a=1,2", Rel: "last"},
+		{Href: "/orders?a=1,2", Rel: "first"},
+		{Href: "https://example.com/docs", Rel: "help", Type: "text/html"},
+	}
+	if !reflect.DeepEqual(links, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, links)
+	}
+
+	// Formatted headers parse back to the same links.
+	if got := ParseLinkHeader(FormatLinkHeader(expected)); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+}
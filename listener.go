@@ -0,0 +1,173 @@
+package itsy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// listenFDsStart is the first file descriptor passed by systemd socket activation.
+const listenFDsStart = 3
+
+// Serve accepts connections on every listener and serves requests until Shutdown is called.
+// Cleartext listeners accept both HTTP/1.1 and HTTP/2 (h2c), with prior knowledge or by upgrading
+// from HTTP/1.1, unless H2C is disabled.
+func (i *Itsy) Serve(listeners ...net.Listener) error {
+	if len(listeners) == 0 {
+		return errors.New("no listeners to serve")
+	}
+
+	i.transitionIndex()
+	if err := i.validateLinks(); err != nil {
+		return err
+	}
+
+	srv := i.httpServer()
+	errs := make(chan error, len(listeners))
+	for _, l := range listeners {
+		i.Logger.Info("Listening", zap.String("network", l.Addr().Network()), zap.String("address", l.Addr().String()))
+		go func(l net.Listener) {
+			errs <- srv.Serve(l)
+		}(l)
+	}
+
+	// Wait for every listener to stop and report the first unexpected error.
+	var first error
+	for range listeners {
+		err := <-errs
+		if err != nil && !errors.Is(err, http.ErrServerClosed) && first == nil {
+			first = err
+			srv.Close()
+		}
+	}
+	if first != nil {
+		return first
+	}
+	return http.ErrServerClosed
+}
+
+// Shutdown gracefully stops the server, waiting for active requests to finish or ctx to expire.
+// The instance reports not-ready for DrainDelay before its listeners are closed. The admin server,
+// if any, is shut down as well, and the errors of both are joined.
+func (i *Itsy) Shutdown(ctx context.Context) error {
+	i.Logger.Info("Shutting down server...")
+	i.draining.Store(true)
+
+	if i.DrainDelay > 0 {
+		t := time.NewTimer(i.DrainDelay)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+		}
+	}
+	var adminErr error
+	if i.admin != nil {
+		adminErr = i.admin.Shutdown(ctx)
+	}
+	return errors.Join(adminErr, i.httpServer().Shutdown(ctx))
+}
+
+// Draining returns true while a graceful shutdown is in progress.
+func (i *Itsy) Draining() bool {
+	return i.draining.Load()
+}
+
+// httpServer returns the HTTP server shared by all listeners, creating it on first use.
+func (i *Itsy) httpServer() *http.Server {
+	i.serverOnce.Do(func() {
+		protocols := new(http.Protocols)
+		protocols.SetHTTP1(true)
+		protocols.SetHTTP2(true)
+		protocols.SetUnencryptedHTTP2(i.H2C)
+		var handler http.Handler = i
+		if i.H2C {
+			// The server handles prior knowledge itself; the h2c handler answers Upgrade: h2c.
+			handler = h2c.NewHandler(i, &http2.Server{})
+		}
+		i.server = &http.Server{
+			Handler:   handler,
+			Protocols: protocols,
+			ErrorLog:  zap.NewStdLog(i.Logger),
+		}
+	})
+	return i.server
+}
+
+// Listen creates a listener for the given network and address, e.g. "tcp" and ":8080".
+// Stale Unix domain socket files are removed before listening.
+func Listen(network, address string) (net.Listener, error) {
+	if network == "unix" {
+		return UnixListener(address)
+	}
+	return net.Listen(network, address)
+}
+
+// TCPListener creates a TCP listener on the given address.
+func TCPListener(address string) (net.Listener, error) {
+	return net.Listen("tcp", address)
+}
+
+// UnixListener creates a Unix domain socket listener at the given path, removing a stale socket file first.
+func UnixListener(path string) (net.Listener, error) {
+	if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	l.(*net.UnixListener).SetUnlinkOnClose(true)
+	return l, nil
+}
+
+// FileListener creates a listener from an inherited file descriptor.
+func FileListener(fd uintptr, name string) (net.Listener, error) {
+	f := os.NewFile(fd, name)
+	if f == nil {
+		return nil, fmt.Errorf("invalid file descriptor %d", fd)
+	}
+	defer f.Close()
+	return net.FileListener(f)
+}
+
+// SystemdListeners returns the listeners passed by systemd socket activation.
+// It returns no listeners if the process was not socket activated.
+func SystemdListeners() ([]net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		return nil, nil
+	}
+
+	listeners := make([]net.Listener, 0, n)
+	for fd := listenFDsStart; fd < listenFDsStart+n; fd++ {
+		l, err := FileListener(uintptr(fd), "LISTEN_FD_"+strconv.Itoa(fd))
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, err
+		}
+		listeners = append(listeners, l)
+	}
+
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+	return listeners, nil
+}
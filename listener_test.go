@@ -0,0 +1,98 @@
+package itsy
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+)
+
+func TestServeMultipleListeners(t *testing.T) {
+	i := New()
+
+	// Register a resource that reports the protocol.
+	r := i.Register("/")
+	r.GET(func(c Context) error {
+		return c.WriteString(c.Request().Proto)
+	})
+
+	// Listen on TCP and on a Unix domain socket.
+	tcp, err := TCPListener("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sock := filepath.Join(t.TempDir(), "itsy.sock")
+	unix, err := UnixListener(sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- i.Serve(tcp, unix) }()
+
+	// Make an HTTP/1.1 request over TCP.
+	if got := get(t, http.DefaultClient, "http://"+tcp.Addr().String()+"/"); got != "HTTP/1.1" {
+		t.Errorf("Expected %q, got %q", "HTTP/1.1", got)
+	}
+
+	// Make an h2c request with prior knowledge over TCP.
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+	h2c := &http.Client{Transport: &http.Transport{Protocols: protocols}}
+	if got := get(t, h2c, "http://"+tcp.Addr().String()+"/"); got != "HTTP/2.0" {
+		t.Errorf("Expected %q, got %q", "HTTP/2.0", got)
+	}
+
+	// Upgrade an HTTP/1.1 connection to h2c.
+	conn, err := net.Dial("tcp", tcp.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	io.WriteString(conn, "GET / HTTP/1.1\r\nHost: itsy\r\nConnection: Upgrade, HTTP2-Settings\r\nUpgrade: h2c\r\nHTTP2-Settings: AAMAAABkAAQCAAAAAAIAAAAA\r\n\r\n")
+	upgrade, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if upgrade.StatusCode != http.StatusSwitchingProtocols || upgrade.Header.Get("Upgrade") != "h2c" {
+		t.Errorf("Expected the upgrade to h2c, got %d %q", upgrade.StatusCode, upgrade.Header.Get("Upgrade"))
+	}
+
+	// Make a request over the Unix domain socket.
+	unixClient := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", sock)
+		},
+	}}
+	if got := get(t, unixClient, "http://unix/"); got != "HTTP/1.1" {
+		t.Errorf("Expected %q, got %q", "HTTP/1.1", got)
+	}
+
+	// Shut down and wait for Serve to return.
+	if err := i.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; !errors.Is(err, http.ErrServerClosed) {
+		t.Errorf("Expected %v, got %v", http.ErrServerClosed, err)
+	}
+}
+
+// get makes a GET request and returns the response body.
+func get(t *testing.T, client *http.Client, url string) string {
+	t.Helper()
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
@@ -9,16 +9,17 @@ import (
 )
 
 // setupLogger sets up the logger for the Itsy instance.
-func setupLogger() *zap.Logger {
+func setupLogger(level zap.AtomicLevel) *zap.Logger {
 	// Encoder Configuration
 	encoderConfig := zap.NewProductionEncoderConfig()
 	encoderConfig.EncodeTime = zapcore.EpochTimeEncoder // Optimized time encoding
 	encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
 	encoder := zapcore.NewJSONEncoder(encoderConfig)
 
-	logWriter := zapcore.AddSync(bufio.NewWriter(os.Stdout))
+	// The buffered writer is locked, since servers log from their own goroutines.
+	logWriter := zapcore.Lock(zapcore.AddSync(bufio.NewWriter(os.Stdout)))
 
-	core := zapcore.NewCore(encoder, zapcore.NewMultiWriteSyncer(zapcore.AddSync(os.Stdout), logWriter), zapcore.DebugLevel)
+	core := zapcore.NewCore(encoder, zapcore.NewMultiWriteSyncer(zapcore.AddSync(os.Stdout), logWriter), level)
 	logger := zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1))
 
 	return logger
@@ -0,0 +1,350 @@
+package itsy
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// MetricsPath is the path of the metrics resource.
+	MetricsPath = "/metrics"
+
+	// MIMETextPrometheus is the Prometheus text exposition format.
+	MIMETextPrometheus = "text/plain; version=0.0.4; charset=utf-8"
+
+	// unmatchedRoute is the route label of requests that matched no resource.
+	unmatchedRoute = "unmatched"
+)
+
+var (
+	// DefaultLatencyBuckets are the default histogram buckets for durations in seconds.
+	DefaultLatencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	// DefaultSizeBuckets are the default histogram buckets for sizes in bytes.
+	DefaultSizeBuckets = []float64{100, 1000, 10000, 100000, 1e6, 1e7}
+)
+
+type (
+	// Metrics is a registry of metrics exposed in the Prometheus text format.
+	Metrics struct {
+		mu       sync.RWMutex
+		families []*metricFamily
+		byName   map[string]*metricFamily
+
+		requests *Counter   // Requests by route, method and status.
+		latency  *Histogram // Request latency by route, method and status.
+		size     *Histogram // Response size by route, method and status.
+		inFlight *Gauge     // Requests currently being served.
+	}
+	// Counter is a metric that only goes up.
+	Counter struct{ family *metricFamily }
+	// Gauge is a metric that can go up and down.
+	Gauge struct{ family *metricFamily }
+	// Histogram is a metric that counts observations in buckets.
+	Histogram struct{ family *metricFamily }
+	// metricFamily is a metric and all of its labelled series.
+	metricFamily struct {
+		name    string
+		help    string
+		kind    string
+		labels  []string
+		buckets []float64
+		mu      sync.Mutex
+		series  map[string]*metricSeries
+	}
+	// metricSeries is a single labelled series of a metric.
+	metricSeries struct {
+		labelValues []string
+		value       float64
+		counts      []uint64
+		count       uint64
+	}
+)
+
+// Metrics enables request metrics and registers the metrics resource.
+func (i *Itsy) Metrics() *Metrics {
+	if i.metrics != nil {
+		return i.metrics
+	}
+	m := NewMetrics()
+	labels := []string{"route", "method", "status"}
+	m.requests = m.Counter("itsy_http_requests_total", "Total number of HTTP requests.", labels...)
+	m.latency = m.Histogram("itsy_http_request_duration_seconds", "HTTP request latency in seconds.", DefaultLatencyBuckets, labels...)
+	m.size = m.Histogram("itsy_http_response_size_bytes", "HTTP response size in bytes.", DefaultSizeBuckets, labels...)
+	m.inFlight = m.Gauge("itsy_http_requests_in_flight", "Number of HTTP requests being served.")
+	i.metrics = m
+
+	r := i.Register(MetricsPath)
+	r.GET(func(c Context) error {
+		c.Response().Header().Set(HeaderContentType, MIMETextPrometheus)
+		_, err := m.WriteTo(c.Response())
+		return err
+	})
+	r.(OperationalMarker).Operational()
+
+	return m
+}
+
+// NewMetrics creates an empty metrics registry.
+func NewMetrics() *Metrics {
+	return &Metrics{byName: make(map[string]*metricFamily)}
+}
+
+// Counter registers a counter, or returns the counter already registered with the name.
+// It panics if the name is registered with another kind or labels.
+func (m *Metrics) Counter(name, help string, labels ...string) *Counter {
+	return &Counter{family: m.register(name, help, "counter", nil, labels)}
+}
+
+// Gauge registers a gauge, or returns the gauge already registered with the name.
+// It panics if the name is registered with another kind or labels.
+func (m *Metrics) Gauge(name, help string, labels ...string) *Gauge {
+	return &Gauge{family: m.register(name, help, "gauge", nil, labels)}
+}
+
+// Histogram registers a histogram, or returns the histogram already registered with the name.
+// It panics if the name is registered with another kind, labels or buckets.
+func (m *Metrics) Histogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+	buckets = append([]float64{}, buckets...)
+	sort.Float64s(buckets)
+	return &Histogram{family: m.register(name, help, "histogram", buckets, labels)}
+}
+
+// register registers a metric family, or returns the family already registered with the name.
+// It panics if that family has another kind, labels or buckets.
+func (m *Metrics) register(name, help, kind string, buckets []float64, labels []string) *metricFamily {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if f, ok := m.byName[name]; ok {
+		if f.kind != kind || !equalStrings(f.labels, labels) || !equalFloats(f.buckets, buckets) {
+			panic("itsy: metric " + name + " is already registered as a " + f.kind + " with labels [" + strings.Join(f.labels, ", ") + "]")
+		}
+		return f
+	}
+	f := &metricFamily{
+		name:    name,
+		help:    help,
+		kind:    kind,
+		labels:  labels,
+		buckets: buckets,
+		series:  make(map[string]*metricSeries),
+	}
+	m.families = append(m.families, f)
+	m.byName[name] = f
+	return f
+}
+
+// equalStrings reports whether a and b hold the same strings in the same order.
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for n := range a {
+		if a[n] != b[n] {
+			return false
+		}
+	}
+	return true
+}
+
+// equalFloats reports whether a and b hold the same numbers in the same order.
+func equalFloats(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for n := range a {
+		if a[n] != b[n] {
+			return false
+		}
+	}
+	return true
+}
+
+// Inc increments the counter by one.
+func (c *Counter) Inc(labelValues ...string) { c.Add(1, labelValues...) }
+
+// Add increments the counter by v, which must not be negative.
+func (c *Counter) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		return
+	}
+	c.family.update(labelValues, func(s *metricSeries) { s.value += v })
+}
+
+// Set sets the gauge to v.
+func (g *Gauge) Set(v float64, labelValues ...string) {
+	g.family.update(labelValues, func(s *metricSeries) { s.value = v })
+}
+
+// Add adds v to the gauge.
+func (g *Gauge) Add(v float64, labelValues ...string) {
+	g.family.update(labelValues, func(s *metricSeries) { s.value += v })
+}
+
+// Inc increments the gauge by one.
+func (g *Gauge) Inc(labelValues ...string) { g.Add(1, labelValues...) }
+
+// Dec decrements the gauge by one.
+func (g *Gauge) Dec(labelValues ...string) { g.Add(-1, labelValues...) }
+
+// Observe records an observation.
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	h.family.update(labelValues, func(s *metricSeries) {
+		if s.counts == nil {
+			s.counts = make([]uint64, len(h.family.buckets))
+		}
+		for n, upper := range h.family.buckets {
+			if v <= upper {
+				s.counts[n]++
+			}
+		}
+		s.value += v
+		s.count++
+	})
+}
+
+// update applies fn to the series with the given label values.
+func (f *metricFamily) update(labelValues []string, fn func(*metricSeries)) {
+	if len(labelValues) != len(f.labels) {
+		return
+	}
+	key := strings.Join(labelValues, "\xff")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	s, ok := f.series[key]
+	if !ok {
+		s = &metricSeries{labelValues: append([]string{}, labelValues...)}
+		f.series[key] = s
+	}
+	fn(s)
+}
+
+// WriteTo writes every metric in the Prometheus text exposition format.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.RLock()
+	families := append([]*metricFamily{}, m.families...)
+	m.mu.RUnlock()
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+	for _, f := range families {
+		f.write(cw)
+	}
+	if err := cw.w.Flush(); err != nil {
+		return cw.n, err
+	}
+	return cw.n, nil
+}
+
+// write writes the family in the text exposition format.
+func (f *metricFamily) write(w *countingWriter) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w.WriteString("# HELP " + f.name + " " + escapeHelp(f.help) + "\n")
+	w.WriteString("# TYPE " + f.name + " " + f.kind + "\n")
+
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s := f.series[key]
+		if f.kind != "histogram" {
+			w.WriteString(f.name + formatLabels(f.labels, s.labelValues, "", "") + " " + formatFloat(s.value) + "\n")
+			continue
+		}
+		for n, upper := range f.buckets {
+			w.WriteString(f.name + "_bucket" + formatLabels(f.labels, s.labelValues, "le", formatFloat(upper)) + " " + strconv.FormatUint(s.counts[n], 10) + "\n")
+		}
+		w.WriteString(f.name + "_bucket" + formatLabels(f.labels, s.labelValues, "le", "+Inf") + " " + strconv.FormatUint(s.count, 10) + "\n")
+		w.WriteString(f.name + "_sum" + formatLabels(f.labels, s.labelValues, "", "") + " " + formatFloat(s.value) + "\n")
+		w.WriteString(f.name + "_count" + formatLabels(f.labels, s.labelValues, "", "") + " " + strconv.FormatUint(s.count, 10) + "\n")
+	}
+}
+
+// track starts tracking a request.
+func (m *Metrics) track() {
+	m.inFlight.Inc()
+}
+
+// observe records a finished request from its route and response.
+func (m *Metrics) observe(c Context, start time.Time) {
+	m.inFlight.Dec()
+
+	route := unmatchedRoute
+	if r := c.Resource(); r != nil {
+		route = r.Path()
+	}
+	res := c.Response()
+	labels := []string{route, c.Request().Method, strconv.Itoa(res.StatusCode)}
+
+	m.requests.Inc(labels...)
+	m.latency.Observe(time.Since(start).Seconds(), labels...)
+	m.size.Observe(float64(res.Size), labels...)
+}
+
+// countingWriter counts the bytes written to a buffered writer.
+type countingWriter struct {
+	w *bufio.Writer
+	n int64
+}
+
+func (w *countingWriter) WriteString(s string) {
+	n, _ := w.w.WriteString(s)
+	w.n += int64(n)
+}
+
+// formatLabels formats label pairs, with an optional extra pair.
+func formatLabels(names, values []string, extraName, extraValue string) string {
+	if len(names) == 0 && extraName == "" {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for n, name := range names {
+		if n > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(name + `="` + escapeLabel(values[n]) + `"`)
+	}
+	if extraName != "" {
+		if len(names) > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(extraName + `="` + extraValue + `"`)
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// formatFloat formats a sample value.
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
@@ -0,0 +1,97 @@
+package itsy
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	i := New()
+	m := i.Metrics()
+
+	// Register a parameterized resource and a custom counter.
+	jobs := m.Counter("jobs_total", "Jobs processed.", "queue")
+	r := i.Register("/users/:id")
+	r.GET(func(c Context) error {
+		jobs.Inc("emails")
+		return c.WriteString("user " + c.GetParamValue("id"))
+	})
+
+	// Make requests to the resource and to a path that doesn't exist.
+	for _, path := range []string{"/users/1", "/users/2", "/nothing"} {
+		i.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(GET, path, nil))
+	}
+
+	rr := httptest.NewRecorder()
+	i.ServeHTTP(rr, httptest.NewRequest(GET, MetricsPath, nil))
+	if rr.Header().Get(HeaderContentType) != MIMETextPrometheus {
+		t.Errorf("Expected content type %q, got %q", MIMETextPrometheus, rr.Header().Get(HeaderContentType))
+	}
+
+	// Requests are labelled by route pattern rather than raw path.
+	body := rr.Body.String()
+	for _, expected := range []string{
+		`# TYPE itsy_http_requests_total counter`,
+		`itsy_http_requests_total{route="/users/:id",method="GET",status="200"} 2`,
+		`itsy_http_requests_total{route="unmatched",method="GET",status="404"} 1`,
+		`itsy_http_request_duration_seconds_count{route="/users/:id",method="GET",status="200"} 2`,
+		`itsy_http_response_size_bytes_bucket{route="/users/:id",method="GET",status="200",le="100"} 2`,
+		`itsy_http_response_size_bytes_sum{route="/users/:id",method="GET",status="200"} 12`,
+		`itsy_http_requests_in_flight 1`,
+		`jobs_total{queue="emails"} 2`,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected %q in:\n%s", expected, body)
+		}
+	}
+}
+
+func TestHistogramExposition(t *testing.T) {
+	m := NewMetrics()
+	h := m.Histogram("latency_seconds", "Latency.", []float64{1, 0.1})
+	h.Observe(0.05)
+	h.Observe(0.5)
+	h.Observe(5)
+
+	var b strings.Builder
+	if _, err := m.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{le="0.1"} 1
+latency_seconds_bucket{le="1"} 2
+latency_seconds_bucket{le="+Inf"} 3
+latency_seconds_sum 5.55
+latency_seconds_count 3
+`
+	if b.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, b.String())
+	}
+}
+
+func TestMetricsReregister(t *testing.T) {
+	m := NewMetrics()
+	c := m.Counter("requests_total", "Requests.", "method")
+	if m.Counter("requests_total", "Requests.", "method").family != c.family {
+		t.Error("Expected the registered counter to be returned")
+	}
+
+	for name, register := range map[string]func(){
+		"kind":    func() { m.Gauge("requests_total", "Requests.", "method") },
+		"labels":  func() { m.Counter("requests_total", "Requests.", "code") },
+		"buckets": func() { m.Histogram("latency_seconds", "Latency.", []float64{0.5}) },
+	} {
+		m.Histogram("latency_seconds", "Latency.", []float64{1})
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected a metric registered with another %s to panic", name)
+				}
+			}()
+			register()
+		}()
+	}
+}
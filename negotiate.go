@@ -0,0 +1,82 @@
+package itsy
+
+import (
+	"strconv"
+	"strings"
+)
+
+// acceptRange is a media range of an Accept header.
+type acceptRange struct {
+	typ     string
+	subtype string
+	q       float64
+}
+
+// parseAccept parses the media ranges of an Accept header.
+func parseAccept(header string) []acceptRange {
+	ranges := make([]acceptRange, 0)
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		typ, subtype, ok := strings.Cut(strings.ToLower(strings.TrimSpace(params[0])), "/")
+		if !ok || typ == "" || subtype == "" {
+			continue
+		}
+
+		r := acceptRange{typ: typ, subtype: subtype, q: 1}
+		for _, param := range params[1:] {
+			name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.ToLower(strings.TrimSpace(name)) != "q" {
+				continue
+			}
+			if q, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil && q >= 0 && q <= 1 {
+				r.q = q
+			}
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges
+}
+
+// negotiateMediaType picks the offer the Accept header prefers, or "" if none is acceptable.
+// Each offer takes the q-value of the most specific range that matches it, and ties go to the earlier offer.
+func negotiateMediaType(accept string, offers []string) string {
+	if len(offers) == 0 {
+		return ""
+	}
+	if strings.TrimSpace(accept) == "" {
+		return offers[0]
+	}
+
+	ranges := parseAccept(accept)
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		typ, subtype, _ := strings.Cut(strings.ToLower(mediaType(offer)), "/")
+
+		q, specificity := 0.0, -1
+		for _, r := range ranges {
+			s := -1
+			switch {
+			case r.typ == typ && r.subtype == subtype:
+				s = 2
+			case r.typ == typ && r.subtype == "*":
+				s = 1
+			case r.typ == "*" && r.subtype == "*":
+				s = 0
+			}
+			if s > specificity {
+				q, specificity = r.q, s
+			}
+		}
+
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+// mediaType returns the media type of a content type without its parameters.
+func mediaType(contentType string) string {
+	t, _, _ := strings.Cut(contentType, ";")
+	return strings.TrimSpace(t)
+}
@@ -0,0 +1,258 @@
+package itsy
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type (
+	// htmlEncoder renders a representation as an HTML document followed by its links.
+	htmlEncoder struct{}
+	// jsonEncoder renders a representation as a JSON document with its links.
+	jsonEncoder struct{}
+	// textEncoder renders a representation as plain text followed by its links.
+	textEncoder struct{}
+	// jsonDocument is the JSON representation of a value and its links.
+	jsonDocument struct {
+		Data     interface{}               `json:"data"`
+		Links    []Link                    `json:"links"`
+		Embedded map[string][]jsonDocument `json:"embedded,omitempty"`
+	}
+	// jsonError is the JSON representation of an error.
+	jsonError struct {
+		Error jsonErrorDetail `json:"error"`
+	}
+	// jsonErrorDetail describes an error.
+	jsonErrorDetail struct {
+		Status int    `json:"status"`
+		Title  string `json:"title"`
+		Detail string `json:"detail"`
+	}
+)
+
+// defaultRepresentations are the representations every instance offers, in order of preference.
+func defaultRepresentations() []registeredRepresentation {
+	return []registeredRepresentation{
+		{mediaType: MIMETextHTML, contentType: MIMETextHTML + "; charset=utf-8", encoder: htmlEncoder{}},
+		{mediaType: MIMEAppJSON, contentType: MIMEAppJSON, encoder: jsonEncoder{}},
+		{mediaType: MIMEAppHALJSON, contentType: MIMEAppHALJSON, encoder: halEncoder{}},
+		{mediaType: MIMEAppSirenJSON, contentType: MIMEAppSirenJSON, encoder: sirenEncoder{}},
+		{mediaType: MIMEAppJSONAPI, contentType: MIMEAppJSONAPI, encoder: jsonapiEncoder{}},
+		{mediaType: MIMEAppCollectionJSON, contentType: MIMEAppCollectionJSON, encoder: collectionJSONEncoder{}},
+		{mediaType: MIMETextPlain, contentType: MIMETextPlain + "; charset=utf-8", encoder: textEncoder{}},
+	}
+}
+
+// Render writes the value and the resource's hypermedia in the representation the Accept header prefers,
+// with the linked resources it embeds. Within the sub-request of an embedded resource, it only records the representation.
+func (c *baseContext) Render(status int, value interface{}) error {
+	res := c.Response()
+	if c.embedding != nil {
+		c.embedding.rep = newRepresentation(c, value)
+		c.embed(c.embedding.rep)
+		res.StatusCode = status
+		return nil
+	}
+	res.Header().Add(HeaderVary, HeaderAccept)
+
+	chosen, ok := c.itsy.negotiateRepresentation(c.Request().Header.Get(HeaderAccept))
+	if !ok {
+		c.itsy.renderError(c, StatusNotAcceptable, "No acceptable representation")
+		return nil
+	}
+
+	rep := newRepresentation(c, value)
+	c.embed(rep)
+
+	res.Header().Set(HeaderContentType, chosen.contentType)
+	res.WriteHeader(status)
+	return chosen.encoder.Encode(res, rep)
+}
+
+// Encode writes the HTML document.
+func (htmlEncoder) Encode(w io.Writer, rep *Representation) error {
+	if _, err := io.WriteString(w, "<!DOCTYPE html>\n<html>\n<body>\n"); err != nil {
+		return err
+	}
+	if err := writeHTMLRepresentation(w, rep); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "</body>\n</html>\n")
+	return err
+}
+
+// EncodeError writes the error as an HTML document.
+func (htmlEncoder) EncodeError(w io.Writer, err *HTTPError) error {
+	_, werr := io.WriteString(w, "<!DOCTYPE html>\n<html>\n<body>\n<h1>"+strconv.Itoa(err.Status)+" "+html.EscapeString(err.Title())+"</h1>\n<p>"+html.EscapeString(err.Message)+"</p>\n</body>\n</html>\n")
+	return werr
+}
+
+// writeHTMLRepresentation writes the data, links, forms and embedded representations as an HTML fragment.
+func writeHTMLRepresentation(w io.Writer, rep *Representation) error {
+	var b strings.Builder
+	if rep.Data != nil {
+		b.WriteString("<div class=\"data\">")
+		writeHTMLValue(&b, genericValue(rep.Data))
+		b.WriteString("</div>\n")
+	}
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return err
+	}
+
+	if links := rep.Hypermedia.Links; len(links) > 0 {
+		renderer := rep.Context.GetTemplateRenderer()
+		if renderer == nil {
+			renderer = &defaultTemplateRenderer{}
+		}
+		if err := renderer.RenderLinks(rep.Context, w, links); err != nil {
+			return err
+		}
+	}
+	if forms := rep.Hypermedia.allForms(); len(forms) > 0 {
+		if err := writeHTMLForms(w, forms); err != nil {
+			return err
+		}
+	}
+
+	for _, rel := range sortedRels(rep.Embedded) {
+		for _, embedded := range rep.Embedded[rel] {
+			if _, err := io.WriteString(w, "<section rel=\""+html.EscapeString(rel)+"\">\n"); err != nil {
+				return err
+			}
+			if err := writeHTMLRepresentation(w, embedded); err != nil {
+				return err
+			}
+			if _, err := io.WriteString(w, "</section>\n"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Encode writes the JSON document.
+func (jsonEncoder) Encode(w io.Writer, rep *Representation) error {
+	return json.NewEncoder(w).Encode(newJSONDocument(rep))
+}
+
+// EncodeError writes the error as a JSON document.
+func (jsonEncoder) EncodeError(w io.Writer, err *HTTPError) error {
+	return json.NewEncoder(w).Encode(jsonError{Error: jsonErrorDetail{Status: err.Status, Title: err.Title(), Detail: err.Message}})
+}
+
+// newJSONDocument creates the JSON document of a representation.
+func newJSONDocument(rep *Representation) jsonDocument {
+	doc := jsonDocument{Data: rep.Data, Links: rep.Hypermedia.Links}
+	if doc.Links == nil {
+		doc.Links = []Link{}
+	}
+	if len(rep.Embedded) > 0 {
+		doc.Embedded = make(map[string][]jsonDocument, len(rep.Embedded))
+		for rel, embedded := range rep.Embedded {
+			for _, e := range embedded {
+				doc.Embedded[rel] = append(doc.Embedded[rel], newJSONDocument(e))
+			}
+		}
+	}
+	return doc
+}
+
+// Encode writes the data as plain text followed by the links, one per line.
+func (textEncoder) Encode(w io.Writer, rep *Representation) error {
+	var b strings.Builder
+	switch v := rep.Data.(type) {
+	case nil:
+	case string:
+		b.WriteString(v + "\n")
+	case fmt.Stringer:
+		b.WriteString(v.String() + "\n")
+	default:
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		b.Write(data)
+		b.WriteString("\n")
+	}
+
+	if links := rep.Hypermedia.Links; len(links) > 0 {
+		b.WriteString("\nLinks:\n")
+		for _, link := range links {
+			b.WriteString(link.Rel + ": " + link.Href)
+			if link.Title != "" {
+				b.WriteString(" (" + link.Title + ")")
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// EncodeError writes the error as plain text.
+func (textEncoder) EncodeError(w io.Writer, err *HTTPError) error {
+	_, werr := io.WriteString(w, err.Error())
+	return werr
+}
+
+// sortedRels returns the link relations of embedded representations in order.
+func sortedRels(embedded map[string][]*Representation) []string {
+	rels := make([]string, 0, len(embedded))
+	for rel := range embedded {
+		rels = append(rels, rel)
+	}
+	sort.Strings(rels)
+	return rels
+}
+
+// genericValue converts a value to maps, slices and scalars through its JSON encoding.
+func genericValue(value interface{}) interface{} {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return fmt.Sprint(value)
+	}
+	return generic
+}
+
+// writeHTMLValue writes a generic value as escaped HTML, with objects as definition lists and arrays as lists.
+func writeHTMLValue(b *strings.Builder, value interface{}) {
+	switch v := value.(type) {
+	case nil:
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		b.WriteString("<dl>")
+		for _, key := range keys {
+			b.WriteString("<dt>" + html.EscapeString(key) + "</dt><dd>")
+			writeHTMLValue(b, v[key])
+			b.WriteString("</dd>")
+		}
+		b.WriteString("</dl>")
+	case []interface{}:
+		b.WriteString("<ul>")
+		for _, item := range v {
+			b.WriteString("<li>")
+			writeHTMLValue(b, item)
+			b.WriteString("</li>")
+		}
+		b.WriteString("</ul>")
+	default:
+		b.WriteString(html.EscapeString(fmt.Sprint(v)))
+	}
+}
@@ -0,0 +1,89 @@
+package itsy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNegotiateMediaType(t *testing.T) {
+	offers := []string{MIMETextHTML, MIMEAppJSON, MIMETextPlain}
+	for accept, expected := range map[string]string{
+		"":                                      MIMETextHTML,
+		"*/*":                                   MIMETextHTML,
+		"application/json":                      MIMEAppJSON,
+		"text/*":                                MIMETextHTML,
+		"text/*;q=0.5, text/plain":              MIMETextPlain,
+		"text/html;q=0.1, application/json;q=1": MIMEAppJSON,
+		"text/html;q=0, */*;q=0.5":              MIMEAppJSON,
+		"application/JSON; charset=utf-8":       MIMEAppJSON,
+		"image/png":                             "",
+		"application/hal+json":                  "",
+	} {
+		if got := negotiateMediaType(accept, offers); got != expected {
+			t.Errorf("Expected %q for %q, got %q", expected, accept, got)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	type user struct {
+		Name string `json:"name"`
+	}
+
+	i := New()
+	i.Register("/users/:id").GET(func(c Context) error {
+		return c.Render(http.StatusOK, user{Name: "<Ada>"})
+	})
+	i.Register("/orders/:id").GET(func(c Context) error {
+		return c.WriteHTML()
+	})
+	if err := i.Resource("/users/:id").Link("/orders/:id", "orders"); err != nil {
+		t.Fatal(err)
+	}
+
+	render := func(accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(GET, "/users/7", nil)
+		req.Header.Set(HeaderAccept, accept)
+		rr := httptest.NewRecorder()
+		i.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// JSON includes the data and the resolved links.
+	rr := render("application/json")
+	if rr.Header().Get(HeaderContentType) != MIMEAppJSON || rr.Header().Get(HeaderVary) != HeaderAccept {
+		t.Errorf("Unexpected headers: %v", rr.Header())
+	}
+	var doc struct {
+		Data  user   `json:"data"`
+		Links []Link `json:"links"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Data.Name != "<Ada>" || len(doc.Links) != 1 || doc.Links[0].Href != "/orders/7" || doc.Links[0].Rel != "orders" {
+		t.Errorf("Unexpected document: %+v", doc)
+	}
+
+	// HTML escapes the data and renders the links.
+	rr = render("text/html, application/json;q=0.9")
+	body := rr.Body.String()
+	if !strings.Contains(body, "<dt>name</dt><dd>&lt;Ada&gt;</dd>") || !strings.Contains(body, `<a href="/orders/7" rel="orders"></a>`) {
+		t.Errorf("Unexpected HTML: %s", body)
+	}
+
+	// Plain text lists the links.
+	rr = render("text/plain")
+	if !strings.Contains(rr.Body.String(), "orders: /orders/7") {
+		t.Errorf("Unexpected text: %s", rr.Body.String())
+	}
+
+	// Unsupported media types are not acceptable.
+	rr = render("image/png")
+	if rr.Code != StatusNotAcceptable {
+		t.Errorf("Expected status %d, got %d", StatusNotAcceptable, rr.Code)
+	}
+}
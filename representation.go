@@ -0,0 +1,182 @@
+package itsy
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+type (
+	// Encoder writes a representation of a resource in one media type.
+	Encoder interface {
+		Encode(w io.Writer, rep *Representation) error // Encode the data and hypermedia.
+	}
+	// EncoderFunc adapts an ordinary function to an Encoder.
+	EncoderFunc func(w io.Writer, rep *Representation) error
+	// ErrorEncoder is implemented by encoders with a dedicated error format.
+	ErrorEncoder interface {
+		EncodeError(w io.Writer, err *HTTPError) error // Encode an error.
+	}
+	// Representation is the state of a resource for one response, handed to an encoder.
+	Representation struct {
+		Context    Context                      // The request context.
+		Resource   Resource                     // The resource, nil for errors.
+		Self       string                       // The URL of the resource.
+		Data       interface{}                  // The resource's data.
+		Hypermedia *Hypermedia                  // The resolved hypermedia controls.
+		Embedded   map[string][]*Representation // Embedded representations by link relation.
+	}
+	// HTTPError is an error with an HTTP status code. Handlers may return it to send that status.
+	HTTPError struct {
+		Status  int    `json:"status"`
+		Message string `json:"message"`
+	}
+	// registeredRepresentation is an encoder registered for a media type.
+	registeredRepresentation struct {
+		mediaType   string
+		contentType string
+		encoder     Encoder
+	}
+)
+
+// Encode calls f(w, rep).
+func (f EncoderFunc) Encode(w io.Writer, rep *Representation) error {
+	return f(w, rep)
+}
+
+// NewHTTPError creates an error with an HTTP status code.
+func NewHTTPError(status int, message string) *HTTPError {
+	return &HTTPError{Status: status, Message: message}
+}
+
+// Error returns the status text and message.
+func (e *HTTPError) Error() string {
+	return e.Title() + ": " + e.Message
+}
+
+// Title returns the status text of the error.
+func (e *HTTPError) Title() string {
+	if text, ok := httpErrors[e.Status]; ok {
+		return text
+	}
+	if text := http.StatusText(e.Status); text != "" {
+		return text
+	}
+	return httpErrors[StatusInternalServerError]
+}
+
+// RegisterRepresentation registers the encoder for a media type, replacing any encoder already registered for it.
+// Registered media types take part in content negotiation after the ones registered before them.
+func (i *Itsy) RegisterRepresentation(mediaType string, encoder Encoder) {
+	mediaType = strings.ToLower(mediaType)
+	contentType := mediaType
+	if strings.HasPrefix(mediaType, "text/") {
+		contentType += "; charset=utf-8"
+	}
+	rep := registeredRepresentation{mediaType: mediaType, contentType: contentType, encoder: encoder}
+
+	for n, existing := range i.representations {
+		if existing.mediaType == mediaType {
+			i.representations[n] = rep
+			return
+		}
+	}
+	i.representations = append(i.representations, rep)
+}
+
+// Representations returns the registered media types in order of preference.
+func (i *Itsy) Representations() []string {
+	mediaTypes := make([]string, len(i.representations))
+	for n, rep := range i.representations {
+		mediaTypes[n] = rep.mediaType
+	}
+	return mediaTypes
+}
+
+// negotiateRepresentation picks the representation the Accept header prefers.
+func (i *Itsy) negotiateRepresentation(accept string) (registeredRepresentation, bool) {
+	chosen := negotiateMediaType(accept, i.Representations())
+	for _, rep := range i.representations {
+		if rep.mediaType == chosen {
+			return rep, true
+		}
+	}
+	return registeredRepresentation{}, false
+}
+
+// newRepresentation creates the representation of the request's resource with the given data.
+func newRepresentation(c Context, data interface{}) *Representation {
+	rep := &Representation{
+		Context:    c,
+		Resource:   c.Resource(),
+		Self:       c.Request().URL.Path,
+		Data:       data,
+		Hypermedia: newHypermedia(),
+		Embedded:   make(map[string][]*Representation),
+	}
+	rep.Hypermedia.Links = responseLinks(c)
+	if rep.Resource != nil {
+		rep.Hypermedia.Actions = resolveActions(rep.Resource, rep.Self)
+		rep.Hypermedia.Forms = resolveForms(c, rep.Resource.Hypermedia().Forms)
+		if machine := stateMachine(rep.Resource); machine != nil {
+			machine.apply(c, rep.Hypermedia)
+		}
+	}
+	if linker, ok := data.(Linker); ok {
+		rep.Hypermedia.Links = append(rep.Hypermedia.Links, linker.Links(c)...)
+	}
+	return rep
+}
+
+// renderError writes an error in the representation the client asks for.
+// Clients that send no Accept header, or accept no registered representation, get plain text.
+// Errors of embedded resources are only logged, since they are left as links.
+func (i *Itsy) renderError(c Context, status int, message string) {
+	if embedded(c) {
+		c.Logger().Debug("Embedded resource failed", zap.Int("status", status), zap.String("message", message))
+		return
+	}
+	res := c.Response()
+	accept := c.Request().Header.Get(HeaderAccept)
+	chosen, ok := i.negotiateRepresentation(accept)
+	if accept == "" || !ok || res.Committed() {
+		i.sendHTTPError(status, message, res, i.Logger)
+		return
+	}
+
+	httpErr := NewHTTPError(status, message)
+	i.Logger.Error("HTTP Error", zap.Int("status", status), zap.String("message", httpErr.Error()))
+
+	res.Header().Add(HeaderVary, HeaderAccept)
+	res.Header().Set(HeaderContentType, chosen.contentType)
+	res.WriteHeader(status)
+
+	var err error
+	if e, ok := chosen.encoder.(ErrorEncoder); ok {
+		err = e.EncodeError(res, httpErr)
+	} else {
+		rep := newRepresentation(c, httpErr)
+		rep.Resource = nil
+		rep.Hypermedia = newHypermedia()
+		err = chosen.encoder.Encode(res, rep)
+	}
+	if err != nil {
+		i.Logger.Error("Error encoding failed", zap.Error(err))
+	}
+}
+
+// handleError writes the error a handler returned, unless the response has already been written.
+func (i *Itsy) handleError(c Context, err error) {
+	if c.Response().Committed() {
+		return
+	}
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		i.renderError(c, httpErr.Status, httpErr.Message)
+		return
+	}
+	i.renderError(c, StatusInternalServerError, "Handler failed")
+}
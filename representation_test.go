@@ -0,0 +1,109 @@
+package itsy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegisterRepresentation(t *testing.T) {
+	i := New()
+
+	// Register a CSV encoder that writes the data and link relations.
+	i.RegisterRepresentation("text/csv", EncoderFunc(func(w io.Writer, rep *Representation) error {
+		rels := make([]string, 0)
+		for _, link := range rep.Hypermedia.Links {
+			rels = append(rels, link.Rel)
+		}
+		_, err := io.WriteString(w, rep.Data.(string)+","+strings.Join(rels, ";"))
+		return err
+	}))
+	if got := i.Representations(); got[len(got)-1] != "text/csv" {
+		t.Fatalf("Expected text/csv to be offered last, got %v", got)
+	}
+
+	i.Register("/report").GET(func(c Context) error {
+		return c.Render(StatusOK, "totals")
+	})
+	i.Register("/archive").GET(func(c Context) error {
+		return c.Render(StatusOK, "archive")
+	})
+	i.Resource("/report").Link("/archive", "archive")
+
+	req := httptest.NewRequest(GET, "/report", nil)
+	req.Header.Set(HeaderAccept, "text/csv")
+	rr := httptest.NewRecorder()
+	i.ServeHTTP(rr, req)
+	if rr.Header().Get(HeaderContentType) != "text/csv; charset=utf-8" || rr.Body.String() != "totals,archive" {
+		t.Errorf("Unexpected response: %v %q", rr.Header(), rr.Body.String())
+	}
+}
+
+func TestDefaultRepresentations(t *testing.T) {
+	i := New()
+	i.Register("/report").GET(func(c Context) error {
+		return c.Render(StatusOK, map[string]string{"total": "42"})
+	})
+
+	// Every built-in format is offered and negotiated with its content type.
+	for _, test := range []struct{ mediaType, contentType string }{
+		{MIMETextHTML, MIMETextHTML + "; charset=utf-8"},
+		{MIMEAppJSON, MIMEAppJSON},
+		{MIMEAppHALJSON, MIMEAppHALJSON},
+		{MIMEAppSirenJSON, MIMEAppSirenJSON},
+		{MIMEAppJSONAPI, MIMEAppJSONAPI},
+		{MIMEAppCollectionJSON, MIMEAppCollectionJSON},
+		{MIMETextPlain, MIMETextPlain + "; charset=utf-8"},
+	} {
+		t.Run(test.mediaType, func(t *testing.T) {
+			if rep, ok := i.negotiateRepresentation(test.mediaType); !ok || rep.mediaType != test.mediaType {
+				t.Fatalf("Expected %s to be offered, got %v", test.mediaType, i.Representations())
+			}
+			req := httptest.NewRequest(GET, "/report", nil)
+			req.Header.Set(HeaderAccept, test.mediaType)
+			rr := httptest.NewRecorder()
+			i.ServeHTTP(rr, req)
+			if rr.Code != StatusOK || rr.Header().Get(HeaderContentType) != test.contentType || !strings.Contains(rr.Body.String(), "42") {
+				t.Errorf("Unexpected response: %d %v %q", rr.Code, rr.Header(), rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestRenderError(t *testing.T) {
+	i := New()
+	i.Register("/orders/:id").GET(func(c Context) error {
+		return NewHTTPError(StatusForbidden, "Not your order")
+	})
+
+	// Errors are encoded in the negotiated representation.
+	req := httptest.NewRequest(GET, "/orders/1", nil)
+	req.Header.Set(HeaderAccept, MIMEAppJSON)
+	rr := httptest.NewRecorder()
+	i.ServeHTTP(rr, req)
+	var doc jsonError
+	if err := json.Unmarshal(rr.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if rr.Code != StatusForbidden || doc.Error.Status != StatusForbidden || doc.Error.Title != "Forbidden" || doc.Error.Detail != "Not your order" {
+		t.Errorf("Unexpected error: %d %+v", rr.Code, doc)
+	}
+
+	// Router errors are encoded too.
+	req = httptest.NewRequest(GET, "/missing", nil)
+	req.Header.Set(HeaderAccept, MIMETextHTML)
+	rr = httptest.NewRecorder()
+	i.ServeHTTP(rr, req)
+	if rr.Code != StatusNotFound || !strings.Contains(rr.Body.String(), "<h1>404 Not Found</h1>") {
+		t.Errorf("Unexpected error: %d %s", rr.Code, rr.Body.String())
+	}
+
+	// Clients without an Accept header get plain text.
+	rr = httptest.NewRecorder()
+	i.ServeHTTP(rr, httptest.NewRequest(GET, "/orders/1", nil))
+	if rr.Body.String() != "Forbidden: Not your order" {
+		t.Errorf("Unexpected error: %q", rr.Body.String())
+	}
+}
@@ -1,28 +1,57 @@
 package itsy
 
-import "errors"
+import "go.uber.org/zap"
 
 type (
 	// Resource is the interface that describes a RESTful resource.
 	Resource interface {
-		GET(HandlerFunc)                   // Set the GET handler of the resource.
-		POST(HandlerFunc)                  // Set the POST handler of the resource.
-		PUT(HandlerFunc)                   // Set the PUT handler of the resource.
-		PATCH(HandlerFunc)                 // Set the PATCH handler of the resource.
-		DELETE(HandlerFunc)                // Set the DELETE handler of the resource.
-		Hypermedia() *Hypermedia           // Get the hypermedia of the resource.
-		Handler(method string) HandlerFunc // Get the handler of the resource.
-		Itsy() *Itsy                       // Get the main framework instance.
-		Link(href, rel string) error       // Link to another resource.
-		Links() []Link                     // Get the links of the resource.
-		Path() string                      // Get the path of the resource.
+		GET(HandlerFunc)                                 // Set the GET handler of the resource.
+		POST(HandlerFunc)                                // Set the POST handler of the resource.
+		PUT(HandlerFunc)                                 // Set the PUT handler of the resource.
+		PATCH(HandlerFunc)                               // Set the PATCH handler of the resource.
+		DELETE(HandlerFunc)                              // Set the DELETE handler of the resource.
+		Hypermedia() *Hypermedia                         // Get the hypermedia of the resource.
+		Handler(method string) HandlerFunc               // Get the handler of the resource.
+		Itsy() *Itsy                                     // Get the main framework instance.
+		Link(href, rel string, opts ...LinkOption) error // Link to another resource.
+		Links() []Link                                   // Get the links of the resource.
+		Path() string                                    // Get the path of the resource.
+	}
+	// ActionDescriber is implemented by resources whose actions can be described, such as those
+	// returned by Register.
+	ActionDescriber interface {
+		Action(method, name string, fields ...Field) // Describe the action of a handler.
+	}
+	// FormProvider is implemented by resources that offer forms, such as those returned by Register.
+	FormProvider interface {
+		Form(form Form) error // Add a form that submits to a resource.
+	}
+	// Embedder is implemented by resources that embed linked resources, such as those returned by Register.
+	Embedder interface {
+		Embed(rel string) // Embed the linked resources with the relation.
+		Embeds() []string // Get the relations whose linked resources are embedded.
+	}
+	// Stateful is implemented by resources with a state machine, such as those returned by Register.
+	Stateful interface {
+		States(initial string, current func(Context) string) // Declare the states of the resource.
+		Transition(t Transition) error                       // Declare a transition between states.
+		StateMachine() *StateMachine                         // Get the state machine, nil without states.
+	}
+	// OperationalMarker is implemented by resources that can be marked operational, such as those
+	// returned by Register.
+	OperationalMarker interface {
+		Operational()        // Exempt the resource from the navigation checks of Validate.
+		IsOperational() bool // Get whether the resource is operational.
 	}
 	// baseResource is the base implementation of the Resource interface.
 	baseResource struct {
-		handlers   map[string]HandlerFunc
-		hypermedia *Hypermedia
-		itsy       *Itsy
-		path       string
+		handlers    map[string]HandlerFunc
+		hypermedia  *Hypermedia
+		itsy        *Itsy
+		path        string
+		embeds      []string
+		states      *StateMachine
+		operational bool
 	}
 )
 
@@ -38,13 +67,15 @@ func newBaseResource(path string, i *Itsy) *baseResource {
 
 // Link management
 
-// Link links to another resource.
-func (r *baseResource) Link(path, rel string) error {
-	if exists := r.itsy.ResourceExists(path); !exists {
-		return errors.New("resource does not exist")
+// Link links to another resource. The path may be a URI template, such as /orders{?page,size},
+// and options set the link's target attributes. The target may be registered later; Validate
+// reports links whose target never is.
+func (r *baseResource) Link(path, rel string, opts ...LinkOption) error {
+	link, err := newLink(path, rel, opts...)
+	if err != nil {
+		return err
 	}
-
-	r.hypermedia.Links = append(r.hypermedia.Links, newLink(path, rel))
+	r.hypermedia.Links = append(r.hypermedia.Links, link)
 
 	return nil
 }
@@ -54,6 +85,90 @@ func (r *baseResource) Links() []Link {
 	return r.hypermedia.Links
 }
 
+// Operational marks the resource as operational, such as health checks and metrics, which
+// clients reach directly rather than by following links. Validate doesn't report operational
+// resources as unreachable or as dead ends.
+func (r *baseResource) Operational() {
+	r.operational = true
+}
+
+// IsOperational gets whether the resource is operational.
+func (r *baseResource) IsOperational() bool {
+	return r.operational
+}
+
+// isOperational reports whether a resource is marked operational.
+func isOperational(r Resource) bool {
+	marker, ok := r.(OperationalMarker)
+	return ok && marker.IsOperational()
+}
+
+// Action management
+
+// Action describes the action of the handler for the given method.
+// Representations include an action for every non-GET handler; declared ones carry a name and fields.
+// Patterns are compiled once; a field with an invalid pattern is logged and reported by Validate,
+// and rejects every submitted value.
+func (r *baseResource) Action(method, name string, fields ...Field) {
+	if compiled, err := compileFields(fields); err != nil {
+		r.itsy.Logger.Error("Invalid action field", zap.String("path", r.path), zap.String("method", method), zap.Error(err))
+	} else {
+		fields = compiled
+	}
+	for n, action := range r.hypermedia.Actions {
+		if action.Method == method {
+			r.hypermedia.Actions[n] = Action{Name: name, Method: method, Fields: fields}
+			return
+		}
+	}
+	r.hypermedia.Actions = append(r.hypermedia.Actions, Action{Name: name, Method: method, Fields: fields})
+}
+
+// Form management
+
+// Form adds a form to the resource. Its action may be a URI template, whose path is filled from
+// the request's parameters when rendered and whose query the fields supply; the method defaults to GET. An error is returned if the action
+// is not a valid URI template or a field's pattern is not a valid regular expression.
+func (r *baseResource) Form(form Form) error {
+	template, err := ParseURITemplate(pathParam.ReplaceAllString(form.Action, "/{$1}"))
+	if err != nil {
+		return err
+	}
+	if form.Fields, err = compileFields(form.Fields); err != nil {
+		return err
+	}
+	form.template = formActionTemplate(template)
+	if form.Method == "" {
+		form.Method = GET
+	}
+	if form.Enctype == "" && form.Method != GET {
+		form.Enctype = MIMEAppForm
+	}
+	r.hypermedia.Forms = append(r.hypermedia.Forms, form)
+	return nil
+}
+
+// Embedding
+
+// Embed embeds the resources the resource links to with the relation in its representations,
+// rendered by their GET handlers within the request.
+func (r *baseResource) Embed(rel string) {
+	r.embeds = append(r.embeds, rel)
+}
+
+// Embeds gets the relations whose linked resources are embedded.
+func (r *baseResource) Embeds() []string {
+	return r.embeds
+}
+
+// resourceEmbeds returns the relations a resource embeds, if it is an Embedder.
+func resourceEmbeds(r Resource) []string {
+	if embedder, ok := r.(Embedder); ok {
+		return embedder.Embeds()
+	}
+	return nil
+}
+
 // Hypermedia management
 
 // Hypermedia gets the hypermedia of the resource.
@@ -72,6 +187,22 @@ func (r *baseResource) Handler(method string) HandlerFunc {
 	return handler
 }
 
+// Methods gets the methods the resource has handlers for, in a stable order.
+func (r *baseResource) Methods() []string {
+	return resourceMethods(r)
+}
+
+// resourceMethods returns the methods a resource has handlers for, in a stable order.
+func resourceMethods(r Resource) []string {
+	methods := make([]string, 0)
+	for _, method := range []string{GET, POST, PUT, PATCH, DELETE} {
+		if r.Handler(method) != nil {
+			methods = append(methods, method)
+		}
+	}
+	return methods
+}
+
 // HTTP Method Handlers
 
 // GET calls the handler when the resource is requested with the GET method.
@@ -1,6 +1,7 @@
 package itsy
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
@@ -43,12 +44,12 @@ func TestResourceLinking(t *testing.T) {
 	// Create a resource that doesn't exist in Itsy's resources
 	newBaseResource("/resource3", i)
 
-	// Test linking resource1 to a non-existing resource
-	err = resource1.Link("/resource3", "nonexistent")
-	if err == nil {
-		t.Fatalf("expected error, got nil")
+	// Links to resources that are never registered are reported by validation
+	if err := resource1.Link("/resource3", "nonexistent"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
 	}
-	if err.Error() != "resource does not exist" {
+	var linkErr *LinkError
+	if err := i.Validate(); !errors.As(err, &linkErr) || linkErr.Problem != "resource does not exist" {
 		t.Fatalf("expected resource does not exist, got %v", err)
 	}
 }
@@ -1,7 +1,11 @@
 package itsy
 
 import (
+	"bufio"
+	"net"
 	"net/http"
+
+	"go.uber.org/zap"
 )
 
 type (
@@ -10,6 +14,10 @@ type (
 		itsy       *Itsy               // The main framework instance.
 		Writer     http.ResponseWriter // The HTTP response writer.
 		StatusCode int                 // The HTTP status code.
+		Size       int64               // The number of body bytes written.
+		committed  bool                // Whether the header has been written.
+		before     []func()            // Called just before the header is written.
+		discard    bool                // Whether the body is discarded, as for HEAD requests.
 	}
 )
 
@@ -18,25 +26,90 @@ func NewResponse(res http.ResponseWriter, i *Itsy) *Response {
 	return &Response{
 		itsy:       i,
 		Writer:     res,
-		StatusCode: -1,
+		StatusCode: StatusOK,
 	}
 }
 
-// Write writes the response body.
-func (r *Response) Write(b []byte) (n int, err error) {
-	// Write the header if it hasn't been written yet.
-	if r.StatusCode == -1 {
-		r.WriteHeader(StatusOK)
-	}
-	n, err = r.Writer.Write(b)
-	return
+// Header returns the response headers, which may be changed until the header is written.
+func (r *Response) Header() http.Header {
+	return r.Writer.Header()
+}
+
+// Before registers a function that is called just before the header is written.
+// It is the last chance to change the status code and headers.
+func (r *Response) Before(fn func()) {
+	r.before = append(r.before, fn)
 }
 
-// WriteHeader writes the response header.
+// Committed returns true once the header has been written.
+func (r *Response) Committed() bool {
+	return r.committed
+}
+
+// WriteHeader writes the status code and headers.
 func (r *Response) WriteHeader(code int) {
 	// Don't write the header if it has already been written.
-	if r.StatusCode != -1 {
+	if r.committed {
+		r.itsy.Logger.Warn("Response already committed", zap.Int("status", r.StatusCode), zap.Int("ignored", code))
 		return
 	}
 	r.StatusCode = code
+	for _, fn := range r.before {
+		fn()
+	}
+	r.committed = true
+	r.Writer.WriteHeader(r.StatusCode)
+}
+
+// Write writes the response body, writing the header first if it hasn't been written yet.
+func (r *Response) Write(b []byte) (n int, err error) {
+	if !r.committed {
+		r.WriteHeader(r.StatusCode)
+	}
+	if r.discard {
+		return len(b), nil
+	}
+	n, err = r.Writer.Write(b)
+	r.Size += int64(n)
+	return
+}
+
+// Flush sends any buffered data to the client.
+func (r *Response) Flush() {
+	if !r.committed {
+		r.WriteHeader(r.StatusCode)
+	}
+	if err := http.NewResponseController(r.Writer).Flush(); err != nil {
+		r.itsy.Logger.Warn("Response flush failed", zap.Error(err))
+	}
+}
+
+// Hijack lets the caller take over the connection.
+func (r *Response) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(r.Writer).Hijack()
+	if err == nil {
+		r.committed = true
+	}
+	return conn, rw, err
+}
+
+// Push initiates an HTTP/2 server push, if a wrapped writer supports it.
+func (r *Response) Push(target string, opts *http.PushOptions) error {
+	w := r.Writer
+	for w != nil {
+		if p, ok := w.(http.Pusher); ok {
+			return p.Push(target, opts)
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			break
+		}
+		w = u.Unwrap()
+	}
+	return http.ErrNotSupported
+}
+
+// Unwrap returns the wrapped response writer, for http.ResponseController.
+func (r *Response) Unwrap() http.ResponseWriter {
+	return r.Writer
 }
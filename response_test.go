@@ -0,0 +1,106 @@
+package itsy
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResponseWriteHeader(t *testing.T) {
+	i := New()
+	i.Register("/created").POST(func(c Context) error {
+		res := c.Response()
+		res.Before(func() {
+			res.Header().Set("X-Before", "called")
+		})
+		res.Header().Set("Location", "/created/1")
+		if res.Committed() {
+			t.Error("Expected the response not to be committed")
+		}
+		res.WriteHeader(http.StatusCreated)
+		if !res.Committed() {
+			t.Error("Expected the response to be committed")
+		}
+		if err := c.WriteString("created"); err != nil {
+			return err
+		}
+		if res.Size != int64(len("created")) {
+			t.Errorf("Expected size %d, got %d", len("created"), res.Size)
+		}
+		return nil
+	})
+
+	rr := httptest.NewRecorder()
+	i.ServeHTTP(rr, httptest.NewRequest(POST, "/created", nil))
+
+	if rr.Code != http.StatusCreated {
+		t.Errorf("Expected status %d, got %d", http.StatusCreated, rr.Code)
+	}
+	if rr.Header().Get("Location") != "/created/1" || rr.Header().Get("X-Before") != "called" {
+		t.Errorf("Unexpected headers: %v", rr.Header())
+	}
+}
+
+func TestResponseHandlerError(t *testing.T) {
+	i := New()
+	i.Register("/fail").GET(func(c Context) error {
+		return errors.New("boom")
+	})
+
+	rr := httptest.NewRecorder()
+	i.ServeHTTP(rr, httptest.NewRequest(GET, "/fail", nil))
+	if rr.Code != StatusInternalServerError {
+		t.Errorf("Expected status %d, got %d", StatusInternalServerError, rr.Code)
+	}
+}
+
+func TestResponseOptionalInterfaces(t *testing.T) {
+	i := New()
+	i.Use(Compress(CompressConfig{}))
+	i.Register("/flush").GET(func(c Context) error {
+		c.Response().Flush()
+		if err := c.Response().Push("/style.css", nil); !errors.Is(err, http.ErrNotSupported) {
+			t.Errorf("Expected %v, got %v", http.ErrNotSupported, err)
+		}
+		return nil
+	})
+	i.Register("/hijack").GET(func(c Context) error {
+		conn, rw, err := c.Response().Hijack()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 8\r\nConnection: close\r\n\r\nhijacked")
+		return rw.Flush()
+	})
+
+	// Flush reaches the underlying writer through the compression writer.
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(GET, "/flush", nil)
+	req.Header.Set(HeaderAcceptEncoding, EncodingGzip)
+	i.ServeHTTP(rr, req)
+	if !rr.Flushed {
+		t.Error("Expected the response to be flushed")
+	}
+
+	// Hijack reaches the connection.
+	server := httptest.NewServer(i)
+	defer server.Close()
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("GET /hijack HTTP/1.1\r\nHost: test\r\n\r\n"))
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.ContentLength != int64(len("hijacked")) {
+		t.Errorf("Expected the hijacked response, got %+v", resp)
+	}
+}
@@ -0,0 +1,101 @@
+package itsy
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// MIMEAppSirenJSON is the Siren JSON media type.
+const MIMEAppSirenJSON = "application/vnd.siren+json"
+
+type (
+	// SirenEntity is a Siren entity, as encoded and decoded.
+	SirenEntity struct {
+		Class      []string               `json:"class,omitempty"`      // The classes of the entity.
+		Rel        []string               `json:"rel,omitempty"`        // The relations of a sub-entity to its parent.
+		Properties map[string]interface{} `json:"properties,omitempty"` // The entity's state.
+		Entities   []SirenEntity          `json:"entities,omitempty"`   // The sub-entities.
+		Actions    []Action               `json:"actions,omitempty"`    // The actions of the entity.
+		Links      []SirenLink            `json:"links"`                // The links, including self.
+		Title      string                 `json:"title,omitempty"`      // A human-readable description of the entity.
+	}
+	// SirenLink is a link of a Siren entity.
+	SirenLink struct {
+		Rel   []string `json:"rel"`             // The relations of the link.
+		Href  string   `json:"href"`            // The URL of the linked resource.
+		Title string   `json:"title,omitempty"` // A human-readable label of the link.
+		Type  string   `json:"type,omitempty"`  // The media type of the linked resource.
+	}
+	// sirenEncoder renders a representation as a Siren entity.
+	sirenEncoder struct{}
+)
+
+// Encode writes the representation as a Siren entity.
+func (sirenEncoder) Encode(w io.Writer, rep *Representation) error {
+	entity, err := newSirenEntity(rep)
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(entity)
+}
+
+// EncodeError writes the error as a Siren entity.
+func (sirenEncoder) EncodeError(w io.Writer, err *HTTPError) error {
+	return json.NewEncoder(w).Encode(SirenEntity{
+		Class:      []string{"error"},
+		Properties: map[string]interface{}{"status": err.Status, "detail": err.Message},
+		Links:      []SirenLink{},
+		Title:      err.Title(),
+	})
+}
+
+// newSirenEntity creates the Siren entity of a representation, with embedded representations as sub-entities.
+func newSirenEntity(rep *Representation) (SirenEntity, error) {
+	properties, err := objectProperties(rep.Data)
+	if err != nil {
+		return SirenEntity{}, err
+	}
+	entity := SirenEntity{
+		Links:   []SirenLink{{Rel: []string{"self"}, Href: rep.Self}},
+		Actions: rep.Hypermedia.Actions,
+	}
+	for _, form := range rep.Hypermedia.Forms {
+		entity.Actions = append(entity.Actions, Action{
+			Name:   form.Name,
+			Title:  form.Title,
+			Method: form.Method,
+			Href:   form.Action,
+			Type:   form.Enctype,
+			Fields: form.Fields,
+		})
+	}
+	if len(properties) > 0 {
+		entity.Properties = properties
+	}
+
+	for _, link := range rep.Hypermedia.Links {
+		entity.Links = append(entity.Links, SirenLink{Rel: []string{link.Rel}, Href: link.Href, Title: link.Title, Type: link.Type})
+	}
+
+	for _, rel := range sortedRels(rep.Embedded) {
+		for _, embedded := range rep.Embedded[rel] {
+			sub, err := newSirenEntity(embedded)
+			if err != nil {
+				return SirenEntity{}, err
+			}
+			sub.Rel = []string{rel}
+			entity.Entities = append(entity.Entities, sub)
+		}
+	}
+	return entity, nil
+}
+
+// Action returns the action with the name.
+func (e *SirenEntity) Action(name string) (Action, bool) {
+	for _, action := range e.Actions {
+		if action.Name == name {
+			return action, true
+		}
+	}
+	return Action{}, false
+}
@@ -0,0 +1,82 @@
+package itsy
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSiren(t *testing.T) {
+	i := New()
+	i.Register("/orders/:id").GET(func(c Context) error {
+		rep := map[string]interface{}{"id": c.GetParamValue("id")}
+		return c.Render(StatusOK, rep)
+	})
+	i.Register("/customers/:id").GET(func(c Context) error {
+		return c.Render(StatusOK, nil)
+	})
+	orders := i.Resource("/orders/:id")
+	orders.PATCH(func(c Context) error { return nil })
+	orders.DELETE(func(c Context) error { return nil })
+	orders.(ActionDescriber).Action(PATCH, "update-order", Field{Name: "quantity", Type: "number", Value: 1})
+	orders.(ActionDescriber).Action(PUT, "replace-order") // No PUT handler, so no action.
+	orders.Link("/customers/:id", "customer")
+
+	req := httptest.NewRequest(GET, "/orders/42", nil)
+	req.Header.Set(HeaderAccept, MIMEAppSirenJSON)
+	rr := httptest.NewRecorder()
+	i.ServeHTTP(rr, req)
+
+	if rr.Header().Get(HeaderContentType) != MIMEAppSirenJSON {
+		t.Fatalf("Unexpected content type: %q", rr.Header().Get(HeaderContentType))
+	}
+	var entity SirenEntity
+	if err := json.Unmarshal(rr.Body.Bytes(), &entity); err != nil {
+		t.Fatal(err)
+	}
+
+	if entity.Properties["id"] != "42" {
+		t.Errorf("Unexpected properties: %v", entity.Properties)
+	}
+	if len(entity.Links) != 2 || entity.Links[0].Rel[0] != "self" || entity.Links[0].Href != "/orders/42" || entity.Links[1].Href != "/customers/42" {
+		t.Errorf("Unexpected links: %+v", entity.Links)
+	}
+
+	// Actions come from the non-GET handlers, with declared names and fields.
+	if len(entity.Actions) != 2 {
+		t.Fatalf("Unexpected actions: %+v", entity.Actions)
+	}
+	update, ok := entity.Action("update-order")
+	if !ok || update.Method != PATCH || update.Href != "/orders/42" || update.Type != MIMEAppForm || len(update.Fields) != 1 || update.Fields[0].Name != "quantity" {
+		t.Errorf("Unexpected update action: %+v", update)
+	}
+	del, ok := entity.Action("delete")
+	if !ok || del.Method != DELETE || del.Type != "" || len(del.Fields) != 0 {
+		t.Errorf("Unexpected delete action: %+v", del)
+	}
+}
+
+func TestSirenSubEntities(t *testing.T) {
+	i := New()
+	i.Register("/orders").GET(func(c Context) error {
+		return nil
+	})
+	c := i.prepareRequestContext(httptest.NewRecorder(), httptest.NewRequest(GET, "/orders", nil), "/orders")
+
+	rep := newRepresentation(c, map[string]int{"count": 1})
+	item := newRepresentation(c, map[string]string{"id": "1"})
+	item.Self = "/orders/1"
+	rep.Embedded["item"] = []*Representation{item}
+
+	entity, err := newSirenEntity(rep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entity.Entities) != 1 {
+		t.Fatalf("Unexpected sub-entities: %+v", entity.Entities)
+	}
+	sub := entity.Entities[0]
+	if sub.Rel[0] != "item" || sub.Properties["id"] != "1" || sub.Links[0].Href != "/orders/1" {
+		t.Errorf("Unexpected sub-entity: %+v", sub)
+	}
+}
@@ -0,0 +1,313 @@
+package itsy
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type (
+	// StateMachine describes the states of a resource, such as an order going from draft to submitted
+	// to shipped, and the transitions between them. Representations only offer the transitions allowed
+	// in the current state, and requests for other transitions are rejected with 409 Conflict.
+	StateMachine struct {
+		resource    Resource
+		initial     string
+		current     func(Context) string
+		transitions []Transition
+	}
+	// Transition is a change of state, performed by a request to a resource with a method.
+	Transition struct {
+		link   Link
+		Name   string             // The name of the transition, used as link relation and form name.
+		Title  string             // A human-readable label of the transition.
+		From   []string           // The states the transition is allowed in.
+		To     string             // The state the transition leads to.
+		Method string             // The method of the request that performs the transition, POST by default.
+		Target string             // The href of the resource that performs the transition, the resource itself by default.
+		Guard  func(Context) bool // Whether the request may perform the transition, in addition to the state.
+		Fields []Field            // The fields of the transition's form.
+	}
+	// transitionIndex maps the requests that perform transitions to the state machines that guard them.
+	transitionIndex map[transitionKey][]transitionGuard
+	// transitionKey identifies the requests to a resource with a method.
+	transitionKey struct {
+		method string
+		target Resource
+	}
+	// transitionGuard names the transitions of a state machine that a request performs.
+	transitionGuard struct {
+		machine *StateMachine
+		names   []string
+	}
+)
+
+// States declares the states of the resource: the initial state and a function that returns
+// the current state for a request. Transitions are only offered and guarded once states are declared.
+func (r *baseResource) States(initial string, current func(Context) string) {
+	machine := r.machine()
+	machine.initial, machine.current = initial, current
+	r.itsy.transitions.Store(nil)
+}
+
+// Transition declares a transition between states of the resource. Its target may be a URI template,
+// filled from the request's parameters when rendered. An error is returned if a field's pattern
+// is not a valid regular expression.
+func (r *baseResource) Transition(t Transition) error {
+	fields, err := compileFields(t.Fields)
+	if err != nil {
+		return err
+	}
+	t.Fields = fields
+	if t.Method == "" {
+		t.Method = POST
+	}
+	if t.Target == "" {
+		t.Target = r.path
+	}
+	var opts []LinkOption
+	if t.Method != GET {
+		opts = append(opts, LinkMethod(t.Method))
+	}
+	if t.Title != "" {
+		opts = append(opts, LinkTitle(t.Title))
+	}
+	link, err := newLink(t.Target, t.Name, opts...)
+	if err != nil {
+		return err
+	}
+	t.link = link
+
+	machine := r.machine()
+	machine.transitions = append(machine.transitions, t)
+	r.itsy.transitions.Store(nil)
+	return nil
+}
+
+// StateMachine gets the state machine of the resource, or nil if it has neither states nor transitions.
+func (r *baseResource) StateMachine() *StateMachine {
+	return r.states
+}
+
+// stateMachine returns the state machine of a resource, or nil if it isn't Stateful or has none.
+func stateMachine(r Resource) *StateMachine {
+	if stateful, ok := r.(Stateful); ok {
+		return stateful.StateMachine()
+	}
+	return nil
+}
+
+// machine returns the state machine of the resource, creating it if needed.
+func (r *baseResource) machine() *StateMachine {
+	if r.states == nil {
+		r.states = &StateMachine{resource: r}
+	}
+	return r.states
+}
+
+// State returns the current state for the request, or "" if the states aren't declared.
+func (m *StateMachine) State(c Context) string {
+	if m.current == nil {
+		return ""
+	}
+	return m.current(c)
+}
+
+// Transitions returns the declared transitions.
+func (m *StateMachine) Transitions() []Transition {
+	return m.transitions
+}
+
+// Allowed returns the transitions allowed in the current state whose guards accept the request,
+// or none if the states aren't declared.
+func (m *StateMachine) Allowed(c Context) []Transition {
+	allowed := make([]Transition, 0)
+	if m.current == nil {
+		return allowed
+	}
+	state := m.State(c)
+	for _, t := range m.transitions {
+		if t.from(state) && (t.Guard == nil || t.Guard(c)) {
+			allowed = append(allowed, t)
+		}
+	}
+	return allowed
+}
+
+// allowedTransitions returns the transitions the state machine of the request's resource allows,
+// computed once per request since states and guards may be costly to evaluate.
+func allowedTransitions(c Context) []Transition {
+	machine := stateMachine(c.Resource())
+	if machine == nil {
+		return nil
+	}
+	bc, ok := c.(*baseContext)
+	if !ok {
+		return machine.Allowed(c)
+	}
+	if bc.allowed == nil {
+		bc.allowed = machine.Allowed(c)
+	}
+	return bc.allowed
+}
+
+// from reports whether the transition is allowed in the state.
+func (t Transition) from(state string) bool {
+	for _, from := range t.From {
+		if from == state {
+			return true
+		}
+	}
+	return false
+}
+
+// transitionLinks returns the resolved links of the transitions.
+func transitionLinks(c Context, transitions []Transition) []Link {
+	links := make([]Link, 0, len(transitions))
+	for _, t := range transitions {
+		links = append(links, t.link)
+	}
+	return resolveLinks(c, links)
+}
+
+// apply adds a form to the hypermedia for each transition allowed for the request that isn't performed
+// with GET, whose links are among the response links. The resource's own actions for methods that
+// perform transitions are replaced by the transitions' forms.
+func (m *StateMachine) apply(c Context, h *Hypermedia) {
+	actions := make([]Action, 0, len(h.Actions))
+	for _, action := range h.Actions {
+		if !m.performs(action.Method) {
+			actions = append(actions, action)
+		}
+	}
+	h.Actions = actions
+
+	allowed := allowedTransitions(c)
+	for n, link := range transitionLinks(c, allowed) {
+		if t := allowed[n]; t.Method != GET {
+			h.Forms = append(h.Forms, Form{
+				Name:    t.Name,
+				Title:   t.Title,
+				Action:  link.Href,
+				Method:  t.Method,
+				Enctype: MIMEAppForm,
+				Fields:  t.Fields,
+			})
+		}
+	}
+}
+
+// performs reports whether a transition is performed with the method on the resource itself.
+func (m *StateMachine) performs(method string) bool {
+	for _, guard := range m.resource.Itsy().transitionIndex()[transitionKey{method, m.resource}] {
+		if guard.machine == m {
+			return true
+		}
+	}
+	return false
+}
+
+// transitionIndex returns the index of the transitions by method and target resource, building
+// it when resources or transitions have changed since it was last built.
+func (i *Itsy) transitionIndex() transitionIndex {
+	if index := i.transitions.Load(); index != nil {
+		return *index
+	}
+	index := make(transitionIndex)
+	for _, path := range i.resourcePaths() {
+		machine := stateMachine(i.resources[path])
+		if machine == nil || machine.current == nil {
+			continue
+		}
+		for _, t := range machine.transitions {
+			target := i.linkTarget(t.Target)
+			if target == nil {
+				continue
+			}
+			key := transitionKey{t.Method, target}
+			guards := index[key]
+			if n := len(guards); n > 0 && guards[n-1].machine == machine {
+				guards[n-1].names = append(guards[n-1].names, t.Name)
+			} else {
+				index[key] = append(guards, transitionGuard{machine: machine, names: []string{t.Name}})
+			}
+		}
+	}
+	i.transitions.Store(&index)
+	return index
+}
+
+// guardTransitions wraps the handler of a request that performs transitions, rejecting it with
+// 409 Conflict and the allowed transitions as Link headers unless a state machine allows one of them.
+func (i *Itsy) guardTransitions(handler HandlerFunc) HandlerFunc {
+	return func(c Context) error {
+		guards := i.transitionIndex()[transitionKey{c.Request().Method, c.Resource()}]
+		if len(guards) == 0 {
+			return handler(c)
+		}
+		var allowed []Transition
+		problems := make([]string, 0, len(guards))
+		for _, guard := range guards {
+			machineAllowed := guard.machine.Allowed(c)
+			for _, t := range machineAllowed {
+				for _, name := range guard.names {
+					if t.Name == name {
+						return handler(c)
+					}
+				}
+			}
+			allowed = append(allowed, machineAllowed...)
+			problems = append(problems, fmt.Sprintf("Transition %s is not allowed in state %q", strings.Join(guard.names, " or "), guard.machine.State(c)))
+		}
+
+		if header := FormatLinkHeader(transitionLinks(c, allowed)); header != "" {
+			c.Response().Header().Add(HeaderLink, header)
+		}
+		return NewHTTPError(StatusConflict, strings.Join(problems, "; "))
+	}
+}
+
+// Mermaid returns the state diagram as a Mermaid state diagram. Guarded transitions are marked.
+func (m *StateMachine) Mermaid() string {
+	var b strings.Builder
+	b.WriteString("stateDiagram-v2\n")
+	if m.initial != "" {
+		fmt.Fprintf(&b, "\t[*] --> %s\n", m.initial)
+	}
+	for _, t := range m.transitions {
+		for _, from := range t.From {
+			fmt.Fprintf(&b, "\t%s --> %s: %s\n", from, t.To, t.label())
+		}
+	}
+	return b.String()
+}
+
+// DOT returns the state diagram in the Graphviz DOT language. Guarded transitions are dashed.
+func (m *StateMachine) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph states {\n")
+	if m.initial != "" {
+		b.WriteString("\tstart [shape=point];\n")
+		fmt.Fprintf(&b, "\tstart -> %s;\n", strconv.Quote(m.initial))
+	}
+	for _, t := range m.transitions {
+		attrs := "label=" + strconv.Quote(t.label())
+		if t.Guard != nil {
+			attrs += ", style=dashed"
+		}
+		for _, from := range t.From {
+			fmt.Fprintf(&b, "\t%s -> %s [%s];\n", strconv.Quote(from), strconv.Quote(t.To), attrs)
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// label returns the name of the transition with its method, marked if guarded.
+func (t Transition) label() string {
+	label := t.Name + " (" + t.Method + ")"
+	if t.Guard != nil {
+		label += " [guarded]"
+	}
+	return label
+}
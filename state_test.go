@@ -0,0 +1,253 @@
+package itsy
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newStateTestItsy(t *testing.T) (*Itsy, map[string]string) {
+	i := New()
+	states := map[string]string{"1": "draft", "2": "submitted", "3": "shipped"}
+	handler := func(to string) HandlerFunc {
+		return func(c Context) error {
+			states[c.GetParamValue("id")] = to
+			return c.Render(StatusOK, map[string]string{"state": to})
+		}
+	}
+
+	order := i.Register("/orders/:id")
+	order.GET(func(c Context) error {
+		return c.Render(StatusOK, map[string]string{"state": states[c.GetParamValue("id")]})
+	})
+	order.DELETE(handler("cancelled"))
+	i.Register("/orders/:id/submit").POST(handler("submitted"))
+	i.Register("/orders/:id/ship").POST(handler("shipped"))
+
+	order.(Stateful).States("draft", func(c Context) string { return states[c.GetParamValue("id")] })
+	for _, transition := range []Transition{
+		{Name: "submit", From: []string{"draft"}, To: "submitted", Target: "/orders/:id/submit", Fields: []Field{{Name: "note"}}},
+		{Name: "ship", From: []string{"submitted"}, To: "shipped", Target: "/orders/:id/ship", Guard: func(c Context) bool {
+			return c.Request().Header.Get(HeaderAuthorization) == "warehouse"
+		}},
+		{Name: "cancel", From: []string{"draft", "submitted"}, To: "cancelled", Method: DELETE},
+	} {
+		if err := order.(Stateful).Transition(transition); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return i, states
+}
+
+func TestStateMachine(t *testing.T) {
+	i, states := newStateTestItsy(t)
+	request := func(method, target, auth string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		req.Header.Set(HeaderAccept, MIMEAppSirenJSON)
+		req.Header.Set(HeaderAuthorization, auth)
+		rr := httptest.NewRecorder()
+		i.ServeHTTP(rr, req)
+		return rr
+	}
+	transitions := func(id, auth string) string {
+		var entity SirenEntity
+		if err := json.Unmarshal(request(GET, "/orders/"+id, auth).Body.Bytes(), &entity); err != nil {
+			t.Fatal(err)
+		}
+		names := make([]string, 0)
+		for _, action := range entity.Actions {
+			names = append(names, action.Method+" "+action.Name+" "+action.Href)
+		}
+		return strings.Join(names, ", ")
+	}
+
+	// Only the transitions of the current state are offered, replacing the DELETE action.
+	for _, test := range []struct{ id, auth, expected string }{
+		{"1", "", "POST submit /orders/1/submit, DELETE cancel /orders/1"},
+		{"2", "", "DELETE cancel /orders/2"},
+		{"2", "warehouse", "POST ship /orders/2/ship, DELETE cancel /orders/2"},
+		{"3", "warehouse", ""},
+	} {
+		if got := transitions(test.id, test.auth); got != test.expected {
+			t.Errorf("Expected %q for order %s, got %q", test.expected, test.id, got)
+		}
+	}
+
+	// Allowed transitions are performed.
+	if rr := request(POST, "/orders/1/submit", ""); rr.Code != StatusOK || states["1"] != "submitted" {
+		t.Errorf("Expected order 1 to be submitted, got %d %s", rr.Code, states["1"])
+	}
+
+	// Others are rejected with the allowed transitions.
+	rr := request(POST, "/orders/1/ship", "")
+	if rr.Code != StatusConflict || states["1"] != "submitted" {
+		t.Fatalf("Expected status %d, got %d", StatusConflict, rr.Code)
+	}
+	if links := ParseLinkHeader(rr.Header().Values(HeaderLink)...); len(links) != 1 || links[0].Rel != "cancel" || links[0].Href != "/orders/1" || links[0].Method != DELETE {
+		t.Errorf("Expected the cancel link, got %+v", links)
+	}
+	if !strings.Contains(rr.Body.String(), `Transition ship is not allowed in state \"submitted\"`) {
+		t.Errorf("Unexpected error: %s", rr.Body.String())
+	}
+	if rr := request(DELETE, "/orders/3", ""); rr.Code != StatusConflict || states["3"] != "shipped" {
+		t.Errorf("Expected shipped orders not to be cancelled, got %d", rr.Code)
+	}
+}
+
+func TestStateDiagram(t *testing.T) {
+	i, _ := newStateTestItsy(t)
+	machine := i.Resource("/orders/:id").(Stateful).StateMachine()
+
+	mermaid := `stateDiagram-v2
+	[*] --> draft
+	draft --> submitted: submit (POST)
+	submitted --> shipped: ship (POST) [guarded]
+	draft --> cancelled: cancel (DELETE)
+	submitted --> cancelled: cancel (DELETE)
+`
+	if got := machine.Mermaid(); got != mermaid {
+		t.Errorf("Expected Mermaid\n%s\ngot\n%s", mermaid, got)
+	}
+
+	dot := `digraph states {
+	start [shape=point];
+	start -> "draft";
+	"draft" -> "submitted" [label="submit (POST)"];
+	"submitted" -> "shipped" [label="ship (POST) [guarded]", style=dashed];
+	"draft" -> "cancelled" [label="cancel (DELETE)"];
+	"submitted" -> "cancelled" [label="cancel (DELETE)"];
+}
+`
+	if got := machine.DOT(); got != dot {
+		t.Errorf("Expected DOT\n%s\ngot\n%s", dot, got)
+	}
+
+	if err := i.Resource("/orders/:id").(Stateful).Transition(Transition{Name: "archive", From: []string{"shipped"}, To: "archived", Target: "/orders/:id/archive"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := i.Validate(); err == nil || !strings.Contains(err.Error(), "/orders/:id -> /orders/:id/archive (archive): transition target has no POST handler") {
+		t.Errorf("Expected the archive transition to be reported, got %v", err)
+	}
+}
+
+func TestTransitionIndex(t *testing.T) {
+	i, _ := newStateTestItsy(t)
+	archive := func() int {
+		rr := httptest.NewRecorder()
+		i.ServeHTTP(rr, httptest.NewRequest(POST, "/orders/1/archive", nil))
+		return rr.Code
+	}
+
+	// Transitions may be declared before their target is registered.
+	if err := i.Resource("/orders/:id").(Stateful).Transition(Transition{Name: "archive", From: []string{"shipped"}, To: "archived", Target: "/orders/:id/archive"}); err != nil {
+		t.Fatal(err)
+	}
+	if code := archive(); code != StatusNotFound {
+		t.Errorf("Expected status %d, got %d", StatusNotFound, code)
+	}
+	i.Register("/orders/:id/archive").POST(func(c Context) error { return c.WriteString("archived") })
+	if code := archive(); code != StatusConflict {
+		t.Errorf("Expected the archive transition to be guarded, got %d", code)
+	}
+}
+
+func TestTransitionLinkHeaders(t *testing.T) {
+	i, _ := newStateTestItsy(t)
+	i.LinkHeaders = true
+
+	// The Link headers offer the transitions allowed in the current state.
+	rr := httptest.NewRecorder()
+	i.ServeHTTP(rr, httptest.NewRequest(GET, "/orders/2", nil))
+	rels := make([]string, 0)
+	for _, link := range ParseLinkHeader(rr.Header().Values(HeaderLink)...) {
+		rels = append(rels, link.Method+" "+link.Rel+" "+link.Href)
+	}
+	if got, expected := strings.Join(rels, ", "), "DELETE cancel /orders/2"; got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
+
+func TestTransitionsStrictLinks(t *testing.T) {
+	i, _ := newStateTestItsy(t)
+	i.StrictLinks = true
+	root := i.Register("/")
+	root.GET(func(c Context) error { return nil })
+	root.Link("/orders/{id}", "order", LinkTemplated())
+
+	// The submit and ship resources are reached through the transitions.
+	if err := i.validateLinks(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestTransitionsWithoutStates(t *testing.T) {
+	i := New()
+	order := i.Register("/orders/:id")
+	order.GET(func(c Context) error { return c.Render(StatusOK, nil) })
+	i.Register("/orders/:id/ship").POST(func(c Context) error { return c.WriteString("shipped") })
+	if err := order.(Stateful).Transition(Transition{Name: "ship", From: []string{"paid"}, To: "shipped", Target: "/orders/:id/ship"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without states, the transitions are reported but not guarded.
+	if err := i.Validate(); err == nil || !strings.Contains(err.Error(), "/orders/:id: transitions declared without States") {
+		t.Errorf("Expected the missing states to be reported, got %v", err)
+	}
+	rr := httptest.NewRecorder()
+	i.ServeHTTP(rr, httptest.NewRequest(POST, "/orders/1/ship", nil))
+	if rr.Code != StatusOK {
+		t.Errorf("Expected status %d, got %d", StatusOK, rr.Code)
+	}
+}
+
+func TestAllowedOncePerRequest(t *testing.T) {
+	i, states := newStateTestItsy(t)
+	i.LinkHeaders = true
+	calls := 0
+	i.Resource("/orders/:id").(Stateful).States("draft", func(c Context) string {
+		calls++
+		return states[c.GetParamValue("id")]
+	})
+
+	// The links, forms and Link headers share the allowed transitions.
+	req := httptest.NewRequest(GET, "/orders/1", nil)
+	req.Header.Set(HeaderAccept, MIMEAppSirenJSON)
+	i.ServeHTTP(httptest.NewRecorder(), req)
+	if calls != 1 {
+		t.Errorf("Expected the state to be read once, got %d", calls)
+	}
+}
+
+func TestTransitionGuards(t *testing.T) {
+	i, states := newStateTestItsy(t)
+
+	// A cart's machine also performs the submit transition of orders.
+	cart := i.Register("/users/:id/cart")
+	cart.GET(func(c Context) error { return c.Render(StatusOK, nil) })
+	cart.(Stateful).States("open", func(c Context) string { return "open" })
+	if err := cart.(Stateful).Transition(Transition{Name: "checkout", From: []string{"open"}, To: "ordered", Target: "/orders/:id/submit"}); err != nil {
+		t.Fatal(err)
+	}
+	states["3"] = "shipped"
+	rr := httptest.NewRecorder()
+	i.ServeHTTP(rr, httptest.NewRequest(POST, "/orders/3/submit", nil))
+	if rr.Code != StatusOK {
+		t.Errorf("Expected the cart's transition to allow the request, got %d", rr.Code)
+	}
+
+	// GET transitions are guarded when embedded too.
+	i.Register("/orders/:id/receipt").GET(func(c Context) error { return c.Render(StatusOK, map[string]string{"receipt": "yes"}) })
+	if err := i.Resource("/orders/:id").(Stateful).Transition(Transition{Name: "receipt", Method: GET, From: []string{"shipped"}, To: "shipped", Target: "/orders/:id/receipt"}); err != nil {
+		t.Fatal(err)
+	}
+	i.Resource("/users/:id/cart").Link("/orders/1/receipt", "receipt")
+	cart.(Embedder).Embed("receipt")
+	req := httptest.NewRequest(GET, "/users/1/cart", nil)
+	req.Header.Set(HeaderAccept, MIMEAppHALJSON)
+	rr = httptest.NewRecorder()
+	i.ServeHTTP(rr, req)
+	if strings.Contains(rr.Body.String(), "yes") {
+		t.Errorf("Expected the receipt of a draft order not to be embedded, got %s", rr.Body.String())
+	}
+}
@@ -0,0 +1,323 @@
+package itsy
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// W3C trace context header names.
+	HeaderTraceParent = "traceparent"
+	HeaderTraceState  = "tracestate"
+
+	// traceFlagSampled is the sampled flag of the trace flags.
+	traceFlagSampled = 0x01
+	// maxTraceStateLength is the maximum length of a tracestate header that is propagated.
+	maxTraceStateLength = 512
+)
+
+type (
+	// TraceID identifies a trace.
+	TraceID [16]byte
+	// SpanID identifies a span.
+	SpanID [8]byte
+	// SpanContext is the propagated part of a span.
+	SpanContext struct {
+		TraceID    TraceID `json:"traceId"`
+		SpanID     SpanID  `json:"spanId"`
+		Flags      byte    `json:"flags"`
+		TraceState string  `json:"traceState,omitempty"`
+	}
+	// Span records a timed operation within a trace.
+	Span struct {
+		Name       string            `json:"name"`
+		Context    SpanContext       `json:"context"`
+		ParentID   SpanID            `json:"parentId"`
+		Start      time.Time         `json:"start"`
+		End        time.Time         `json:"end"`
+		Attributes map[string]string `json:"attributes,omitempty"`
+		Error      string            `json:"error,omitempty"`
+
+		tracer *tracer // The tracer that exports the span.
+		onEnd  func()  // Called when the span ends.
+		ended  bool    // Whether the span has ended.
+	}
+	// SpanExporter receives spans as they end.
+	SpanExporter interface {
+		ExportSpan(span Span) // Export a finished span.
+	}
+	// JSONExporter writes spans to a writer as JSON lines.
+	JSONExporter struct {
+		mu sync.Mutex
+		w  io.Writer
+	}
+	// InMemoryExporter keeps spans in memory, for tests.
+	InMemoryExporter struct {
+		mu    sync.Mutex
+		spans []Span
+	}
+	// tracer creates and exports spans.
+	tracer struct {
+		exporter SpanExporter
+	}
+	// traceTransport propagates the trace context of a request context to outbound requests.
+	traceTransport struct {
+		base   http.RoundTripper
+		tracer *tracer
+		parent SpanContext
+	}
+)
+
+// Tracing enables W3C trace context propagation and records spans to the exporter.
+func (i *Itsy) Tracing(exporter SpanExporter) {
+	i.tracer = &tracer{exporter: exporter}
+}
+
+// String returns the hex encoding of the trace ID.
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+
+// IsValid returns true if the trace ID is not all zeros.
+func (id TraceID) IsValid() bool { return id != TraceID{} }
+
+// MarshalText encodes the trace ID as hex.
+func (id TraceID) MarshalText() ([]byte, error) { return []byte(id.String()), nil }
+
+// String returns the hex encoding of the span ID.
+func (id SpanID) String() string { return hex.EncodeToString(id[:]) }
+
+// IsValid returns true if the span ID is not all zeros.
+func (id SpanID) IsValid() bool { return id != SpanID{} }
+
+// MarshalText encodes the span ID as hex.
+func (id SpanID) MarshalText() ([]byte, error) { return []byte(id.String()), nil }
+
+// IsValid returns true if the span context has a trace ID and a span ID.
+func (sc SpanContext) IsValid() bool { return sc.TraceID.IsValid() && sc.SpanID.IsValid() }
+
+// Sampled returns true if the sampled flag is set.
+func (sc SpanContext) Sampled() bool { return sc.Flags&traceFlagSampled != 0 }
+
+// TraceParent formats the span context as a traceparent header value.
+func (sc SpanContext) TraceParent() string {
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + hex.EncodeToString([]byte{sc.Flags})
+}
+
+// ParseTraceParent parses a traceparent header value.
+func ParseTraceParent(s string) (SpanContext, error) {
+	var sc SpanContext
+	s = strings.TrimSpace(s)
+	if len(s) < 55 || s[2] != '-' || s[35] != '-' || s[52] != '-' {
+		return sc, errors.New("malformed traceparent")
+	}
+
+	version, err := decodeLowerHex(s[0:2])
+	if err != nil || version[0] == 0xff {
+		return sc, errors.New("invalid traceparent version")
+	}
+	// Version 00 has exactly four fields; later versions may append more.
+	if (version[0] == 0 && len(s) != 55) || (len(s) > 55 && s[55] != '-') {
+		return sc, errors.New("malformed traceparent")
+	}
+
+	traceID, err := decodeLowerHex(s[3:35])
+	if err != nil {
+		return sc, errors.New("invalid trace ID")
+	}
+	spanID, err := decodeLowerHex(s[36:52])
+	if err != nil {
+		return sc, errors.New("invalid parent ID")
+	}
+	flags, err := decodeLowerHex(s[53:55])
+	if err != nil {
+		return sc, errors.New("invalid trace flags")
+	}
+
+	copy(sc.TraceID[:], traceID)
+	copy(sc.SpanID[:], spanID)
+	sc.Flags = flags[0]
+	if !sc.IsValid() {
+		return SpanContext{}, errors.New("invalid traceparent IDs")
+	}
+	return sc, nil
+}
+
+// decodeLowerHex decodes lowercase hex.
+func decodeLowerHex(s string) ([]byte, error) {
+	if strings.ToLower(s) != s {
+		return nil, errors.New("uppercase hex")
+	}
+	return hex.DecodeString(s)
+}
+
+// extractSpanContext reads the trace context headers of a request.
+func extractSpanContext(req *http.Request) (SpanContext, bool) {
+	sc, err := ParseTraceParent(req.Header.Get(HeaderTraceParent))
+	if err != nil {
+		return SpanContext{}, false
+	}
+	if state := strings.Join(req.Header.Values(HeaderTraceState), ","); len(state) <= maxTraceStateLength {
+		sc.TraceState = state
+	}
+	return sc, true
+}
+
+// injectSpanContext writes the trace context headers to a request.
+func injectSpanContext(req *http.Request, sc SpanContext) {
+	if !sc.IsValid() {
+		return
+	}
+	req.Header.Set(HeaderTraceParent, sc.TraceParent())
+	if sc.TraceState != "" {
+		req.Header.Set(HeaderTraceState, sc.TraceState)
+	} else {
+		req.Header.Del(HeaderTraceState)
+	}
+}
+
+// start starts a span that is a child of parent, or the root of a new trace if parent is invalid.
+func (t *tracer) start(name string, parent SpanContext) *Span {
+	s := &Span{
+		Name:       name,
+		Start:      time.Now(),
+		Attributes: make(map[string]string),
+		tracer:     t,
+	}
+	if parent.IsValid() {
+		s.Context = parent
+		s.ParentID = parent.SpanID
+	} else {
+		rand.Read(s.Context.TraceID[:])
+		s.Context.Flags = traceFlagSampled
+	}
+	rand.Read(s.Context.SpanID[:])
+	return s
+}
+
+// SetAttribute sets an attribute of the span.
+func (s *Span) SetAttribute(key, value string) {
+	if s == nil {
+		return
+	}
+	s.Attributes[key] = value
+}
+
+// RecordError records an error on the span.
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.Error = err.Error()
+}
+
+// Finish ends the span and exports it if the trace is sampled.
+func (s *Span) Finish() {
+	if s == nil || s.ended {
+		return
+	}
+	s.ended = true
+	s.End = time.Now()
+	if s.onEnd != nil {
+		s.onEnd()
+	}
+	if s.tracer != nil && s.Context.Sampled() {
+		s.tracer.exporter.ExportSpan(*s)
+	}
+}
+
+// NewJSONExporter creates an exporter that writes spans to w as JSON lines, e.g. os.Stdout.
+func NewJSONExporter(w io.Writer) *JSONExporter {
+	return &JSONExporter{w: w}
+}
+
+// ExportSpan writes the span as a JSON line.
+func (e *JSONExporter) ExportSpan(span Span) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	json.NewEncoder(e.w).Encode(span)
+}
+
+// NewInMemoryExporter creates an exporter that keeps spans in memory.
+func NewInMemoryExporter() *InMemoryExporter {
+	return &InMemoryExporter{}
+}
+
+// ExportSpan keeps the span.
+func (e *InMemoryExporter) ExportSpan(span Span) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, span)
+}
+
+// Spans returns the exported spans in the order they ended.
+func (e *InMemoryExporter) Spans() []Span {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]Span{}, e.spans...)
+}
+
+// Reset discards the exported spans.
+func (e *InMemoryExporter) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = nil
+}
+
+// RoundTrip records a client span and propagates its context to the outbound request.
+func (t *traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.tracer == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	span := t.tracer.start("HTTP "+req.Method+" "+req.URL.Host, t.parent)
+	defer span.Finish()
+	span.SetAttribute("http.method", req.Method)
+	span.SetAttribute("http.url", req.URL.String())
+
+	req = req.Clone(req.Context())
+	injectSpanContext(req, span.Context)
+
+	res, err := t.base.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	span.SetAttribute("http.status_code", strconv.Itoa(res.StatusCode))
+	return res, nil
+}
+
+// traced wraps a handler in a span.
+func traced(name string, handler HandlerFunc) HandlerFunc {
+	return func(c Context) error {
+		span := c.StartSpan(name)
+		err := handler(c)
+		span.RecordError(err)
+		span.Finish()
+		return err
+	}
+}
+
+// traceLogger returns the logger annotated with the trace of the span context.
+func traceLogger(logger *zap.Logger, sc SpanContext) *zap.Logger {
+	if !sc.IsValid() {
+		return logger
+	}
+	return logger.With(zap.String("trace_id", sc.TraceID.String()), zap.String("span_id", sc.SpanID.String()))
+}
+
+// middlewareName returns the name of a middleware function, for span names.
+func middlewareName(mw Middleware) string {
+	name := runtime.FuncForPC(reflect.ValueOf(mw).Pointer()).Name()
+	return strings.TrimPrefix(name[strings.LastIndex(name, "/")+1:], "itsy.")
+}
@@ -0,0 +1,110 @@
+package itsy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	sc, err := ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || sc.SpanID.String() != "00f067aa0ba902b7" || !sc.Sampled() {
+		t.Errorf("Unexpected span context: %+v", sc)
+	}
+	if sc.TraceParent() != "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01" {
+		t.Errorf("Unexpected traceparent: %s", sc.TraceParent())
+	}
+
+	for _, invalid := range []string{
+		"",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	} {
+		if _, err := ParseTraceParent(invalid); err == nil {
+			t.Errorf("Expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestTracing(t *testing.T) {
+	exporter := NewInMemoryExporter()
+	i := New()
+	i.Tracing(exporter)
+
+	// Register a middleware.
+	i.Use(func(c Context, next HandlerFunc) HandlerFunc {
+		return next
+	})
+
+	// Create a downstream server that records the propagated headers.
+	var outbound http.Header
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		outbound = r.Header.Clone()
+	}))
+	defer downstream.Close()
+
+	// Register a handler that calls the downstream server through the context.
+	var handlerContext SpanContext
+	i.Register("/orders/:id").GET(func(c Context) error {
+		handlerContext = c.SpanContext()
+		resp, err := c.Client().Get(downstream.URL)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		return c.WriteString("ok")
+	})
+
+	// Make a request that continues an existing trace.
+	req := httptest.NewRequest(GET, "/orders/1", nil)
+	req.Header.Set(HeaderTraceParent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.Header.Set(HeaderTraceState, "vendor=value")
+	i.ServeHTTP(httptest.NewRecorder(), req)
+
+	// The handler sees the incoming trace.
+	if handlerContext.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("Unexpected trace ID: %s", handlerContext.TraceID)
+	}
+
+	// The outbound request propagates the trace.
+	sc, err := ParseTraceParent(outbound.Get(HeaderTraceParent))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc.TraceID != handlerContext.TraceID || outbound.Get(HeaderTraceState) != "vendor=value" {
+		t.Errorf("Unexpected outbound headers: %v", outbound)
+	}
+
+	// Spans are recorded for routing, middleware, the handler, the client call and the request.
+	spans := exporter.Spans()
+	names := make([]string, len(spans))
+	byName := make(map[string]Span)
+	for n, span := range spans {
+		names[n] = span.Name
+		byName[span.Name] = span
+	}
+	expected := []string{"routing", "HTTP GET " + downstream.Listener.Addr().String(), "handler", "middleware TestTracing.func1", "HTTP GET /orders/:id"}
+	if len(names) != len(expected) {
+		t.Fatalf("Expected spans %v, got %v", expected, names)
+	}
+	for n := range expected {
+		if names[n] != expected[n] {
+			t.Fatalf("Expected spans %v, got %v", expected, names)
+		}
+	}
+
+	// Spans are nested under the request span, which continues the incoming trace.
+	root := byName["HTTP GET /orders/:id"]
+	if root.ParentID.String() != "00f067aa0ba902b7" || root.Attributes["http.route"] != "/orders/:id" {
+		t.Errorf("Unexpected request span: %+v", root)
+	}
+	if byName["routing"].ParentID != root.Context.SpanID || byName["handler"].ParentID != byName["middleware TestTracing.func1"].Context.SpanID {
+		t.Errorf("Unexpected span hierarchy: %+v", spans)
+	}
+}
@@ -0,0 +1,360 @@
+package itsy
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type (
+	// URITemplate is a parsed RFC 6570 URI template.
+	URITemplate struct {
+		raw   string
+		parts []templatePart
+	}
+	// templatePart is a literal or an expression of a URI template.
+	templatePart struct {
+		literal string
+		op      *templateOperator
+		vars    []templateVar
+	}
+	// templateVar is a variable of an expression.
+	templateVar struct {
+		name    string
+		prefix  int  // The maximum length of the value, 0 for all of it.
+		explode bool // Whether composite values are exploded.
+	}
+	// templateOperator describes how an expression is expanded.
+	templateOperator struct {
+		op       string // The operator character, "" for simple string expansion.
+		first    string // The prefix of the expansion.
+		sep      string // The separator of the values.
+		named    bool   // Whether values are prefixed with their name.
+		ifEmpty  string // The suffix of a named empty value.
+		reserved bool   // Whether reserved characters are allowed.
+	}
+)
+
+// templateOperators are the expression operators of RFC 6570, level 4.
+var templateOperators = map[string]*templateOperator{
+	"":  {op: "", first: "", sep: ","},
+	"+": {op: "+", first: "", sep: ",", reserved: true},
+	".": {op: ".", first: ".", sep: "."},
+	"/": {op: "/", first: "/", sep: "/"},
+	";": {op: ";", first: ";", sep: ";", named: true},
+	"?": {op: "?", first: "?", sep: "&", named: true, ifEmpty: "="},
+	"&": {op: "&", first: "&", sep: "&", named: true, ifEmpty: "="},
+	"#": {op: "#", first: "#", sep: ",", reserved: true},
+}
+
+// ParseURITemplate parses an RFC 6570 URI template.
+func ParseURITemplate(template string) (*URITemplate, error) {
+	t := &URITemplate{raw: template}
+	rest := template
+	for rest != "" {
+		start := strings.IndexAny(rest, "{}")
+		if start < 0 {
+			t.parts = append(t.parts, templatePart{literal: rest})
+			break
+		}
+		if rest[start] == '}' {
+			return nil, fmt.Errorf("uri template %q: unmatched }", template)
+		}
+		if start > 0 {
+			t.parts = append(t.parts, templatePart{literal: rest[:start]})
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("uri template %q: unclosed expression", template)
+		}
+		part, err := parseTemplateExpression(rest[start+1 : start+end])
+		if err != nil {
+			return nil, fmt.Errorf("uri template %q: %w", template, err)
+		}
+		t.parts = append(t.parts, part)
+		rest = rest[start+end+1:]
+	}
+	return t, nil
+}
+
+// MustParseURITemplate parses a URI template and panics if it is invalid.
+func MustParseURITemplate(template string) *URITemplate {
+	t, err := ParseURITemplate(template)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// parseTemplateExpression parses the inside of an expression.
+func parseTemplateExpression(expression string) (templatePart, error) {
+	if expression == "" {
+		return templatePart{}, errors.New("empty expression")
+	}
+	op := templateOperators[expression[:1]]
+	if op != nil && op.op != "" {
+		expression = expression[1:]
+	} else if strings.ContainsAny(expression[:1], "=,!@|") {
+		return templatePart{}, fmt.Errorf("reserved operator %q", expression[:1])
+	} else {
+		op = templateOperators[""]
+	}
+
+	part := templatePart{op: op}
+	for _, spec := range strings.Split(expression, ",") {
+		v := templateVar{name: spec}
+		if strings.HasSuffix(spec, "*") {
+			v.name, v.explode = spec[:len(spec)-1], true
+		} else if name, prefix, ok := strings.Cut(spec, ":"); ok {
+			n, err := strconv.Atoi(prefix)
+			if err != nil || n <= 0 || n >= 10000 {
+				return templatePart{}, fmt.Errorf("invalid prefix %q", spec)
+			}
+			v.name, v.prefix = name, n
+		}
+		if !validTemplateVarName(v.name) {
+			return templatePart{}, fmt.Errorf("invalid variable name %q", v.name)
+		}
+		part.vars = append(part.vars, v)
+	}
+	return part, nil
+}
+
+// validTemplateVarName reports whether a variable name consists of letters, digits, underscores,
+// dots and percent-encoded octets.
+func validTemplateVarName(name string) bool {
+	if name == "" || name[0] == '.' || name[len(name)-1] == '.' {
+		return false
+	}
+	for n := 0; n < len(name); n++ {
+		c := name[n]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '_', c == '.':
+		case c == '%' && n+2 < len(name) && isHex(name[n+1]) && isHex(name[n+2]):
+			n += 2
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the template.
+func (t *URITemplate) String() string {
+	return t.raw
+}
+
+// Variables returns the names of the template's variables, in order of appearance.
+func (t *URITemplate) Variables() []string {
+	names := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, part := range t.parts {
+		for _, v := range part.vars {
+			if !seen[v.name] {
+				seen[v.name] = true
+				names = append(names, v.name)
+			}
+		}
+	}
+	return names
+}
+
+// requiredVariables returns the variables outside of query and fragment expressions,
+// which a URI needs to locate its resource.
+func (t *URITemplate) requiredVariables() []string {
+	names := make([]string, 0)
+	for _, part := range t.parts {
+		if part.op == nil || part.op.op == "?" || part.op.op == "&" || part.op.op == "#" {
+			continue
+		}
+		for _, v := range part.vars {
+			names = append(names, v.name)
+		}
+	}
+	return names
+}
+
+// Expand expands the template. Values may be strings, string slices, string maps or
+// anything fmt can format; variables without a value expand to nothing.
+func (t *URITemplate) Expand(values map[string]interface{}) string {
+	var b strings.Builder
+	for _, part := range t.parts {
+		if part.op == nil {
+			b.WriteString(part.literal)
+			continue
+		}
+		b.WriteString(part.expand(part.vars, values))
+	}
+	return b.String()
+}
+
+// PartialExpand expands the variables that have a value and keeps the others as expressions,
+// so that the result is a template for the rest. It reports whether variables remain.
+func (t *URITemplate) PartialExpand(values map[string]interface{}) (string, bool) {
+	var b strings.Builder
+	remaining := false
+	for _, part := range t.parts {
+		if part.op == nil {
+			b.WriteString(part.literal)
+			continue
+		}
+
+		defined, undefined := make([]templateVar, 0), make([]string, 0)
+		for _, v := range part.vars {
+			if templateValueDefined(values[v.name]) {
+				defined = append(defined, v)
+			} else {
+				undefined = append(undefined, v.spec())
+			}
+		}
+		if len(undefined) == 0 {
+			b.WriteString(part.expand(part.vars, values))
+			continue
+		}
+		remaining = true
+
+		// Operators that prefix every value can expand some variables and keep the rest;
+		// the others can only be kept whole.
+		if part.op.first == "" || part.op.op == "#" {
+			specs := make([]string, 0, len(part.vars))
+			for _, v := range part.vars {
+				specs = append(specs, v.spec())
+			}
+			b.WriteString("{" + part.op.op + strings.Join(specs, ",") + "}")
+			continue
+		}
+		expanded := part.expand(defined, values)
+		op := part.op.op
+		if expanded != "" && op == "?" {
+			op = "&"
+		}
+		b.WriteString(expanded + "{" + op + strings.Join(undefined, ",") + "}")
+	}
+	return b.String(), remaining
+}
+
+// spec returns the variable as written in an expression.
+func (v templateVar) spec() string {
+	switch {
+	case v.explode:
+		return v.name + "*"
+	case v.prefix > 0:
+		return v.name + ":" + strconv.Itoa(v.prefix)
+	}
+	return v.name
+}
+
+// expand expands the given variables of an expression.
+func (part templatePart) expand(vars []templateVar, values map[string]interface{}) string {
+	op := part.op
+	expanded := make([]string, 0, len(vars))
+	for _, v := range vars {
+		value := values[v.name]
+		if !templateValueDefined(value) {
+			continue
+		}
+
+		switch value := value.(type) {
+		case []string:
+			if v.explode {
+				items := make([]string, len(value))
+				for n, item := range value {
+					items[n] = op.nameValue(v.name, encodeTemplateValue(item, op.reserved))
+				}
+				expanded = append(expanded, strings.Join(items, op.sep))
+				continue
+			}
+			items := make([]string, len(value))
+			for n, item := range value {
+				items[n] = encodeTemplateValue(item, op.reserved)
+			}
+			expanded = append(expanded, op.nameValue(v.name, strings.Join(items, ",")))
+		case map[string]string:
+			keys := make([]string, 0, len(value))
+			for key := range value {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			items := make([]string, 0, len(keys))
+			for _, key := range keys {
+				if v.explode {
+					items = append(items, op.pair(encodeTemplateValue(key, op.reserved), encodeTemplateValue(value[key], op.reserved)))
+				} else {
+					items = append(items, encodeTemplateValue(key, op.reserved)+","+encodeTemplateValue(value[key], op.reserved))
+				}
+			}
+			if v.explode {
+				expanded = append(expanded, strings.Join(items, op.sep))
+			} else {
+				expanded = append(expanded, op.nameValue(v.name, strings.Join(items, ",")))
+			}
+		default:
+			s := fmt.Sprint(value)
+			if v.prefix > 0 && len([]rune(s)) > v.prefix {
+				s = string([]rune(s)[:v.prefix])
+			}
+			expanded = append(expanded, op.nameValue(v.name, encodeTemplateValue(s, op.reserved)))
+		}
+	}
+	if len(expanded) == 0 {
+		return ""
+	}
+	return op.first + strings.Join(expanded, op.sep)
+}
+
+// nameValue prefixes a value with its name for named operators.
+func (op *templateOperator) nameValue(name, value string) string {
+	if !op.named {
+		return value
+	}
+	return op.pair(name, value)
+}
+
+// pair joins a name and a value for named operators.
+func (op *templateOperator) pair(name, value string) string {
+	if value == "" {
+		return name + op.ifEmpty
+	}
+	return name + "=" + value
+}
+
+// templateValueDefined reports whether a value counts as defined: empty lists and maps do not.
+func templateValueDefined(value interface{}) bool {
+	switch value := value.(type) {
+	case nil:
+		return false
+	case []string:
+		return len(value) > 0
+	case map[string]string:
+		return len(value) > 0
+	}
+	return true
+}
+
+// encodeTemplateValue percent-encodes everything but unreserved characters and,
+// if allowed, reserved characters and existing percent-encoded octets.
+func encodeTemplateValue(s string, reserved bool) string {
+	var b strings.Builder
+	for n := 0; n < len(s); n++ {
+		c := s[n]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', strings.IndexByte("-._~", c) >= 0:
+			b.WriteByte(c)
+		case reserved && strings.IndexByte(":/?#[]@!$&'()*+,;=", c) >= 0:
+			b.WriteByte(c)
+		case reserved && c == '%' && n+2 < len(s) && isHex(s[n+1]) && isHex(s[n+2]):
+			b.WriteString(s[n : n+3])
+			n += 2
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// isHex reports whether c is a hexadecimal digit.
+func isHex(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
@@ -0,0 +1,123 @@
+package itsy
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestURITemplateExpand(t *testing.T) {
+	// Examples from RFC 6570.
+	values := map[string]interface{}{
+		"var":   "value",
+		"hello": "Hello World!",
+		"path":  "/foo/bar",
+		"empty": "",
+		"x":     "1024",
+		"y":     "768",
+		"list":  []string{"red", "green", "blue"},
+		"keys":  map[string]string{"semi": ";", "dot": ".", "comma": ","},
+	}
+	for template, expected := range map[string]string{
+		"{var}":             "value",
+		"{hello}":           "Hello%20World%21",
+		"{+path}/here":      "/foo/bar/here",
+		"X{#var}":           "X#value",
+		"map?{x,y}":         "map?1024,768",
+		"{var:3}":           "val",
+		"{list}":            "red,green,blue",
+		"{list*}":           "red,green,blue",
+		"{keys}":            "comma,%2C,dot,.,semi,%3B",
+		"{keys*}":           "comma=%2C,dot=.,semi=%3B",
+		"X{.list*}":         "X.red.green.blue",
+		"{/var,x}/here":     "/value/1024/here",
+		"{;x,y,empty}":      ";x=1024;y=768;empty",
+		"{?x,y,empty}":      "?x=1024&y=768&empty=",
+		"{?x,undef}":        "?x=1024",
+		"?fixed=yes{&x}":    "?fixed=yes&x=1024",
+		"{&keys*}":          "&comma=%2C&dot=.&semi=%3B",
+		"{/list*,path:4}":   "/red/green/blue/%2Ffoo",
+		"{?list*}":          "?list=red&list=green&list=blue",
+		"/orders{?undef}":   "/orders",
+		"{+path,x}{#undef}": "/foo/bar,1024",
+	} {
+		tmpl, err := ParseURITemplate(template)
+		if err != nil {
+			t.Errorf("Failed to parse %q: %v", template, err)
+			continue
+		}
+		if got := tmpl.Expand(values); got != expected {
+			t.Errorf("Expected %q for %q, got %q", expected, template, got)
+		}
+	}
+}
+
+func TestURITemplatePartialExpand(t *testing.T) {
+	values := map[string]interface{}{"id": "42", "page": "2"}
+	for template, expected := range map[string]struct {
+		href      string
+		remaining bool
+	}{
+		"/orders/{id}":             {"/orders/42", false},
+		"/orders/{id}{?page,size}": {"/orders/42?page=2{&size}", true},
+		"/orders{?size}":           {"/orders{?size}", true},
+		"/orders/{id}/{item}":      {"/orders/42/{item}", true},
+		"/orders{/id,item}":        {"/orders/42{/item}", true},
+		"/search{?q}{&page}":       {"/search{?q}&page=2", true},
+	} {
+		href, remaining := MustParseURITemplate(template).PartialExpand(values)
+		if href != expected.href || remaining != expected.remaining {
+			t.Errorf("Expected %q (%t) for %q, got %q (%t)", expected.href, expected.remaining, template, href, remaining)
+		}
+	}
+}
+
+func TestParseURITemplateErrors(t *testing.T) {
+	for _, template := range []string{"{", "}", "{}", "{=x}", "{x y}", "{x:0}", "{x:abc}", "{.}"} {
+		if _, err := ParseURITemplate(template); err == nil {
+			t.Errorf("Expected an error for %q", template)
+		}
+	}
+	if vars := MustParseURITemplate("/orders/{id}{?page,size,id}").Variables(); len(vars) != 3 || vars[2] != "size" {
+		t.Errorf("Unexpected variables: %v", vars)
+	}
+}
+
+func TestTemplatedLinks(t *testing.T) {
+	i := New()
+	i.Register("/orders").GET(func(c Context) error {
+		return c.Render(StatusOK, nil)
+	})
+	i.Register("/orders/:id").GET(func(c Context) error {
+		return c.Render(StatusOK, nil)
+	})
+	if err := i.Resource("/orders/:id").Link("/orders{?page,size}", "collection"); err != nil {
+		t.Fatal(err)
+	}
+	if err := i.Resource("/orders/:id").Link("/orders/{id}{?expand}", "expanded"); err != nil {
+		t.Fatal(err)
+	}
+	if err := i.Resource("/orders/:id").Link("/orders/{id", "broken"); err == nil {
+		t.Error("Expected an error for an invalid template")
+	}
+
+	req := httptest.NewRequest(GET, "/orders/42", nil)
+	req.Header.Set(HeaderAccept, MIMEAppJSON)
+	rr := httptest.NewRecorder()
+	i.ServeHTTP(rr, req)
+	var doc struct {
+		Links []Link `json:"links"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Links) != 2 {
+		t.Fatalf("Unexpected links: %+v", doc.Links)
+	}
+	if doc.Links[0].Href != "/orders{?page,size}" || !doc.Links[0].Templated {
+		t.Errorf("Unexpected collection link: %+v", doc.Links[0])
+	}
+	if doc.Links[1].Href != "/orders/42{?expand}" || !doc.Links[1].Templated {
+		t.Errorf("Unexpected expanded link: %+v", doc.Links[1])
+	}
+}
@@ -0,0 +1,242 @@
+package itsy
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+type (
+	// LinkError describes a link that can't be rendered as declared.
+	LinkError struct {
+		Resource string // The path of the linking resource.
+		Rel      string // The relation of the link.
+		Href     string // The href of the link.
+		Problem  string // What is wrong with the link.
+	}
+	// ResourceError describes a resource that clients can't navigate to or from.
+	ResourceError struct {
+		Resource string // The path of the resource.
+		Problem  string // What is wrong with the resource.
+	}
+)
+
+// Error returns the link and its problem.
+func (e *LinkError) Error() string {
+	return e.Resource + " -> " + e.Href + " (" + e.Rel + "): " + e.Problem
+}
+
+// Error returns the resource and its problem.
+func (e *ResourceError) Error() string {
+	return e.Resource + ": " + e.Problem
+}
+
+// Validate checks the hypermedia graph and returns its problems joined, or nil:
+//   - links and forms whose target is not a registered resource, transitions whose
+//     target has no handler for their method, and transitions declared without States;
+//   - action fields whose pattern is not a valid regular expression;
+//   - path variables of a link that can't be filled from a parameter of the linking
+//     resource, a constant or a handler value, unless the link is declared LinkTemplated
+//     (query and fragment variables are optional);
+//   - resources that can't be reached by following links, forms and transitions from the root
+//     resource, if there is one;
+//   - resources with a GET handler but nowhere to navigate to, which are dead ends for clients.
+//
+// Links to absolute URLs are not checked. Operational resources, such as those registered
+// by Health, Metrics and JSONLD, are neither reported as unreachable nor as dead ends.
+func (i *Itsy) Validate() error {
+	var errs []error
+	for _, path := range i.resourcePaths() {
+		resource := i.resources[path]
+		params := routeParams(path)
+		for _, link := range resource.Links() {
+			if external(link.Href) {
+				continue
+			}
+			if i.linkTarget(link.Href) == nil {
+				errs = append(errs, &LinkError{Resource: path, Rel: link.Rel, Href: link.Href, Problem: "resource does not exist"})
+			}
+			if link.template == nil || link.forClients {
+				continue
+			}
+			for _, variable := range link.template.requiredVariables() {
+				if problem := linkVariableProblem(link, variable, params); problem != "" {
+					errs = append(errs, &LinkError{Resource: path, Rel: link.Rel, Href: link.Href, Problem: problem})
+				}
+			}
+		}
+		for _, action := range resource.Hypermedia().Actions {
+			if _, err := compileFields(action.Fields); err != nil {
+				errs = append(errs, &ResourceError{Resource: path, Problem: "action " + action.Name + ": " + err.Error()})
+			}
+		}
+		for _, form := range resource.Hypermedia().Forms {
+			if !external(form.Action) && i.linkTarget(form.Action) == nil {
+				errs = append(errs, &LinkError{Resource: path, Rel: form.Name, Href: form.Action, Problem: "form target does not exist"})
+			}
+		}
+		if machine := stateMachine(resource); machine != nil {
+			if machine.current == nil {
+				errs = append(errs, &ResourceError{Resource: path, Problem: "transitions declared without States"})
+			}
+			for _, t := range machine.transitions {
+				if i.linkTarget(t.Target) == nil || i.linkTarget(t.Target).Handler(t.Method) == nil {
+					errs = append(errs, &LinkError{Resource: path, Rel: t.Name, Href: t.Target, Problem: "transition target has no " + t.Method + " handler"})
+				}
+			}
+		}
+	}
+
+	if reachable := i.reachable("/"); reachable != nil {
+		for _, path := range i.resourcePaths() {
+			if !reachable[path] && !isOperational(i.resources[path]) {
+				errs = append(errs, &ResourceError{Resource: path, Problem: "not reachable from /"})
+			}
+		}
+	}
+	for _, path := range i.resourcePaths() {
+		if resource := i.resources[path]; resource.Handler(GET) != nil && len(navigationTargets(resource)) == 0 && !isOperational(resource) {
+			errs = append(errs, &ResourceError{Resource: path, Problem: "dead end without links"})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateLinks validates the hypermedia graph when the app starts. Problems are logged,
+// or returned if StrictLinks is set.
+func (i *Itsy) validateLinks() error {
+	err := i.Validate()
+	if err == nil {
+		return nil
+	}
+	if i.StrictLinks {
+		return err
+	}
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		i.Logger.Warn("Invalid hypermedia", zap.Error(e))
+	}
+	return nil
+}
+
+// resourcePaths returns the paths of the resources in order.
+func (i *Itsy) resourcePaths() []string {
+	paths := make([]string, 0, len(i.resources))
+	for path := range i.resources {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// linkTarget returns the resource a link href points to, or nil. Segments are matched
+// like requests are routed, so /orders/{orderId} and /orders/42 both point to /orders/:id.
+// Literal segments win over parameters.
+func (i *Itsy) linkTarget(href string) Resource {
+	segments := splitPath(linkPath(href))
+
+	var target Resource
+	best := -1
+	for path, resource := range i.resources {
+		literals := matchSegments(splitPath(path), segments)
+		if literals > best || literals == best && literals >= 0 && path < target.Path() {
+			target, best = resource, literals
+		}
+	}
+	return target
+}
+
+// matchSegments returns how many literal segments of a route match those of a path,
+// or -1 if the path doesn't match the route.
+func matchSegments(route, segments []string) int {
+	if len(route) != len(segments) {
+		return -1
+	}
+	literals := 0
+	for n, segment := range route {
+		switch {
+		case segment == segments[n]:
+			literals++
+		case strings.HasPrefix(segment, ":") && segments[n] != "":
+		default:
+			return -1
+		}
+	}
+	return literals
+}
+
+// reachable returns the paths of the resources reachable from the root by following links,
+// submitting forms and performing transitions, or nil if there is no root resource.
+func (i *Itsy) reachable(root string) map[string]bool {
+	resource := i.resources[root]
+	if resource == nil {
+		return nil
+	}
+	reachable := map[string]bool{root: true}
+	queue := []Resource{resource}
+	for len(queue) > 0 {
+		resource, queue = queue[0], queue[1:]
+		for _, href := range navigationTargets(resource) {
+			if target := i.linkTarget(href); target != nil && !reachable[target.Path()] {
+				reachable[target.Path()] = true
+				queue = append(queue, target)
+			}
+		}
+	}
+	return reachable
+}
+
+// navigationTargets returns the hrefs clients can navigate to from the resource: those of its
+// links, the actions of its forms and the targets of its transitions.
+func navigationTargets(resource Resource) []string {
+	hrefs := make([]string, 0)
+	for _, link := range resource.Links() {
+		hrefs = append(hrefs, link.Href)
+	}
+	for _, form := range resource.Hypermedia().Forms {
+		hrefs = append(hrefs, form.Action)
+	}
+	if machine := stateMachine(resource); machine != nil {
+		for _, t := range machine.transitions {
+			hrefs = append(hrefs, t.Target)
+		}
+	}
+	return hrefs
+}
+
+// external reports whether an href is an absolute URL, outside of the application.
+func external(href string) bool {
+	return strings.Contains(href, "://") || strings.HasPrefix(href, "//")
+}
+
+// linkVariableProblem describes why a variable of a link can never be filled, or returns "".
+func linkVariableProblem(link Link, variable string, params map[string]bool) string {
+	if link.mapping != nil {
+		for n := len(link.mapping.params) - 1; n >= 0; n-- {
+			m := link.mapping.params[n]
+			if m.target != variable {
+				continue
+			}
+			if m.kind == paramFromRequest && !params[m.source] {
+				return "variable {" + variable + "} is mapped from :" + m.source + ", which is not a parameter of the resource"
+			}
+			return ""
+		}
+	}
+	if !params[variable] {
+		return "variable {" + variable + "} can never be filled"
+	}
+	return ""
+}
+
+// routeParams returns the names of the parameters of a route path.
+func routeParams(path string) map[string]bool {
+	params := make(map[string]bool)
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, ":") {
+			params[segment[1:]] = true
+		}
+	}
+	return params
+}
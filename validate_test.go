@@ -0,0 +1,115 @@
+package itsy
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	i := New()
+
+	// Links may be declared before their targets are registered.
+	root := i.Register("/")
+	root.GET(func(c Context) error { return nil })
+	root.Link("/users/{id}", "user", LinkTemplated())
+	root.Link("https://example.com/docs", "help")
+	i.Register("/users/:id").GET(func(c Context) error { return nil })
+	i.Register("/users/:userId/orders/:orderId").GET(func(c Context) error { return nil })
+	i.Register("/orders").GET(func(c Context) error { return nil })
+	i.Register("/orders/:id").GET(func(c Context) error { return nil })
+
+	users := i.Resource("/users/:id")
+	users.Link("/users/:userId/orders/:orderId", "order", LinkParam("userId", "id"), LinkValue("orderId", "order"))
+	users.Link("/orders{?page,size}", "orders")
+	orders := i.Resource("/users/:userId/orders/:orderId")
+	orders.Link("/users/:id", "owner", LinkParam("id", "userId"))
+	orders.Link("/orders/:id", "canonical", LinkValue("id", "order"))
+	i.Resource("/orders").Link("/orders/42", "first")
+	i.Resource("/orders/:id").Link("/orders", "collection")
+	if err := i.Validate(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	orders.Link("/orders/:id", "unmapped")
+	orders.Link("/users/:id", "wrong", LinkParam("id", "customerId"))
+	orders.Link("/customers/:id", "customer", LinkParam("id", "userId"))
+	i.Register("/orphan").GET(func(c Context) error { return nil })
+	i.Register("/orphan/actions").POST(func(c Context) error { return nil })
+
+	err := i.Validate()
+	var linkErr *LinkError
+	if !errors.As(err, &linkErr) {
+		t.Fatalf("Expected a link error, got %v", err)
+	}
+	expected := []string{
+		"/users/:userId/orders/:orderId -> /orders/:id (unmapped): variable {id} can never be filled",
+		"/users/:userId/orders/:orderId -> /users/:id (wrong): variable {id} is mapped from :customerId, which is not a parameter of the resource",
+		"/users/:userId/orders/:orderId -> /customers/:id (customer): resource does not exist",
+		"/orphan: not reachable from /",
+		"/orphan/actions: not reachable from /",
+		"/orphan: dead end without links",
+	}
+	if err.Error() != strings.Join(expected, "\n") {
+		t.Errorf("Expected:\n%s\ngot:\n%s", strings.Join(expected, "\n"), err.Error())
+	}
+
+	// Strict validation refuses to serve.
+	i.StrictLinks = true
+	l, lerr := net.Listen("tcp", "127.0.0.1:0")
+	if lerr != nil {
+		t.Fatal(lerr)
+	}
+	defer l.Close()
+	if serr := i.Serve(l); serr == nil || serr.Error() != err.Error() {
+		t.Errorf("Expected the validation error, got %v", serr)
+	}
+}
+
+func TestValidateOperational(t *testing.T) {
+	i := New()
+	i.StrictLinks = true
+	root := i.Register("/")
+	root.GET(func(c Context) error { return nil })
+	root.Link("/orders", "orders")
+	i.Register("/orders").GET(func(c Context) error { return nil })
+	i.Resource("/orders").Link("/", "home")
+
+	// Operational resources are reached directly and have no links.
+	if _, err := i.Health(); err != nil {
+		t.Fatal(err)
+	}
+	i.Metrics()
+	if err := i.JSONLD(JSONLDConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	i.Register("/version").GET(func(c Context) error { return nil })
+	i.Resource("/version").(OperationalMarker).Operational()
+	if err := i.validateLinks(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestLinkTarget(t *testing.T) {
+	i := New()
+	i.Register("/orders/:id").GET(func(c Context) error { return nil })
+	i.Register("/orders/new").GET(func(c Context) error { return nil })
+	i.Register("/orders").GET(func(c Context) error { return nil })
+
+	for href, expected := range map[string]string{
+		"/orders/{orderId}":     "/orders/:id",
+		"/orders/:orderId":      "/orders/:id",
+		"/orders/42":            "/orders/:id",
+		"/orders/new":           "/orders/new",
+		"/orders{?page}":        "/orders",
+		"/orders/42/items":      "",
+		"/customers/:id":        "",
+		"/orders/{id}{?expand}": "/orders/:id",
+	} {
+		target := i.linkTarget(href)
+		if (target == nil && expected != "") || (target != nil && target.Path() != expected) {
+			t.Errorf("Expected %q for %q, got %v", expected, href, target)
+		}
+	}
+}